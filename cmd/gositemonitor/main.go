@@ -14,6 +14,7 @@ import (
 	"github.com/sairamkumarm/gositemonitor/pkg/aggregator"
 	"github.com/sairamkumarm/gositemonitor/pkg/analyser"
 	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/heartbeat"
 	"github.com/sairamkumarm/gositemonitor/pkg/logger"
 	"github.com/sairamkumarm/gositemonitor/pkg/notification"
 	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
@@ -38,7 +39,7 @@ func main() {
 	defer logger.Log.Sync()
 
 	logger.Log.Info("Starting GoSiteMonitor",
-		zap.Any("config", config.ProdConfig))
+		zap.Any("config", config.ProdConfig.Redacted()))
 
 	// b, _ := json.MarshalIndent(config.ProdConfig, "", " ")
 	// logger.Log.Info("loaded config", zap.String("config", string(b)))
@@ -77,10 +78,15 @@ func main() {
 
 	//Initialize stats map
 	analyser.FillInitialUrls(config.ProdConfig.URLs)
+	analyser.FillHeartbeats(config.ProdConfig.Heartbeats)
+	err = analyser.LoadBaselines(config.ProdConfig.OutputDir)
+	if err != nil {
+		logger.Log.Error("Error loading content baselines: ", zap.Error(err))
+	}
 
 	fmt.Println("Ready to commence operations.")
 
-	jobs := make(chan string, len(config.ProdConfig.URLs))
+	jobs := make(chan config.Target, len(config.ProdConfig.Targets))
 	results := make(chan pinger.PingResult, 100)
 	permits := make(chan struct{}, config.ProdConfig.RateLimitPerSec)
 
@@ -90,7 +96,7 @@ func main() {
 
 	//job refiller to fill jobs channel periodically with urls to ping
 	wg.Add(1) //wait for job refiller
-	go scheduler.JobHandler(jobs, config.ProdConfig.URLs, config.ProdConfig.RateLimitPerSec, config.ProdConfig.GetRequestIntervalDuration(), finish, &wg)
+	go scheduler.JobHandler(jobs, config.ProdConfig.Targets, config.ProdConfig.RateLimitPerSec, config.ProdConfig.GetRequestIntervalDuration(), finish, &wg)
 
 	timeout := time.Duration(config.ProdConfig.RequestTimeOutSecs) * time.Second
 	//resuse a shared httpclient in all the workers, common transport settings are configured here
@@ -104,6 +110,8 @@ func main() {
 			DisableCompression:    false,
 		},
 	}
+	//requests go through the global proxy when one is set, targets can override it
+	pinger.SetProxy(client.Transport.(*http.Transport), config.ProdConfig.ProxyURL())
 	//spawn workers, they wait internally for jobs and permits from their channels
 	for i := 0; i < config.ProdConfig.WorkerCount; i++ {
 		wg.Add(1) //wait for worker
@@ -111,6 +119,12 @@ func main() {
 
 	}
 
+	//serve check-ins for push monitors, their results join the same results channel
+	if len(config.ProdConfig.Heartbeats) > 0 {
+		wg.Add(1) //wait for heartbeat listener
+		go heartbeat.Listen(config.ProdConfig.HeartbeatListen, config.ProdConfig.Heartbeats, results, finish, cancel, &wg)
+	}
+
 	//read results channel and log outputs
 	wg.Add(1) //wait for aggregator
 	go aggregator.Aggregate(results, config.ProdConfig.OutputDir, finish, cancel, &wg)
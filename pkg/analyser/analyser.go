@@ -2,14 +2,19 @@ package analyser
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
 	"github.com/sairamkumarm/gositemonitor/pkg/logger"
 	"github.com/sairamkumarm/gositemonitor/pkg/notification"
 	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
 	"go.uber.org/zap"
 )
 
+// consecutive failures before a target is reported as an outage
+const defaultFailThreshold = 3
+
 type Stat struct {
 	Url              string
 	OutageStart      time.Time
@@ -17,9 +22,16 @@ type Stat struct {
 	ConsecutiveFails int
 	TotalFails       int
 	MaxLatency       int64
+	MaxLatencyPhases pinger.Phases
+	FailedAssertion  string
+	ActualValue      string
+	CheckState       string
+	StatusLine       string
+	failThreshold    int
 }
 
 var Stats = make(map[string]*Stat)
+var statsMu sync.Mutex
 
 func FillInitialUrls(urls []string) {
 	for _, url := range urls {
@@ -27,18 +39,36 @@ func FillInitialUrls(urls []string) {
 	}
 }
 
+// FillHeartbeats adds heartbeat monitors, a single missed check-in is already an outage
+func FillHeartbeats(heartbeats []config.Heartbeat) {
+	for _, h := range heartbeats {
+		Stats[h.URL()] = &Stat{Url: h.URL(), failThreshold: 1}
+	}
+}
+
 func AnalyseResult(res pinger.PingResult, finish context.Context) {
-	_, ok := Stats[res.URL]
+	if analyseAuth(res, finish) || analyseProxy(res, finish) {
+		return
+	}
+	analyseCertificate(res, finish)
+	analyseContent(res, finish)
+	analyseWarning(res, finish)
+	analyseBudget(res, finish)
+	analyseAudit(res, finish)
+	stat, ok := statFor(res)
 	if ok {
-		stat := Stats[res.URL]
-		if res.Status == -1 || res.Status >= 400 {
+		if res.Failed() {
 			if stat.OutageStart.IsZero() { //first error, possible start of outage
 				stat.OutageStart = res.TimestampUTC
 			}
 			stat.OutageLatest = res.TimestampUTC //latest time of outage
+			stat.FailedAssertion = res.FailedAssertion
+			stat.ActualValue = res.ActualValue
+			stat.CheckState = res.CheckState
+			stat.StatusLine = res.StatusLine
 			stat.ConsecutiveFails++
 			stat.TotalFails++
-			if stat.ConsecutiveFails == 3 {
+			if stat.ConsecutiveFails == stat.threshold() {
 				logger.Log.Error("Possible outage in progress", zap.Any("outage", stat))
 				//log and write to notification channel about ongoing outage
 				statcopy := *stat
@@ -68,8 +98,38 @@ func AnalyseResult(res pinger.PingResult, finish context.Context) {
 				stat.ConsecutiveFails = 0
 				stat.OutageLatest = time.Time{} //sets time.Time to zero value
 				stat.OutageStart = time.Time{}
+				stat.FailedAssertion = ""
+				stat.ActualValue = ""
+				stat.CheckState = ""
+				stat.StatusLine = ""
+			}
+			if res.ResponseMS > stat.MaxLatency {
+				stat.MaxLatency = res.ResponseMS
+				stat.MaxLatencyPhases = res.Phases
 			}
-			stat.MaxLatency = max(stat.MaxLatency, res.ResponseMS)
 		}
 	}
 }
+
+// statFor returns the stat a result counts towards, the first result of a new variant of a known
+// url gets its own stat so a failure on one variant is not hidden by the others
+func statFor(res pinger.PingResult) (*Stat, bool) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	stat, ok := Stats[res.Key()]
+	if !ok && res.Variant != "" {
+		if base, known := Stats[res.URL]; known {
+			stat = &Stat{Url: res.Key(), failThreshold: base.failThreshold}
+			Stats[res.Key()] = stat
+			ok = true
+		}
+	}
+	return stat, ok
+}
+
+func (s *Stat) threshold() int {
+	if s.failThreshold > 0 {
+		return s.failThreshold
+	}
+	return defaultFailThreshold
+}
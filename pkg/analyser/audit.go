@@ -0,0 +1,68 @@
+package analyser
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type AuditAlert struct {
+	Url      string
+	Failed   []pinger.AuditFailure //rules that started failing with this result
+	Restored []string              //rules that passed again with this result
+	Failing  []string              //every rule still failing
+}
+
+// failing audit rules per target, a target with a clean audit has no entry
+var auditStates = make(map[string]map[string]bool)
+var auditMu sync.Mutex
+
+// analyseAudit compares a result's security audit with the previous one and reports rules that
+// regressed or were fixed. The first audit of a target counts any failing rule as a regression
+func analyseAudit(res pinger.PingResult, finish context.Context) {
+	if !res.Audited() {
+		return
+	}
+	alert := auditChange(res.Key(), res.AuditFailures)
+	if len(alert.Failed) > 0 {
+		logger.Log.Warn("Security audit regressed", zap.Any("audit", alert))
+		emit("Security audit regressed", alert, finish)
+	}
+	if len(alert.Restored) > 0 {
+		logger.Log.Info("Security audit restored", zap.Any("audit", alert))
+		emit("Security audit restored", alert, finish)
+	}
+}
+
+// auditChange records the rules failing in a target's latest audit and returns which of them are new
+// and which earlier failures passed this time
+func auditChange(key string, failures []pinger.AuditFailure) AuditAlert {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	previous := auditStates[key]
+	current := make(map[string]bool, len(failures))
+	alert := AuditAlert{Url: key}
+	for _, failure := range failures {
+		current[failure.Rule] = true
+		alert.Failing = append(alert.Failing, failure.Rule)
+		if !previous[failure.Rule] {
+			alert.Failed = append(alert.Failed, failure)
+		}
+	}
+	for rule := range previous {
+		if !current[rule] {
+			alert.Restored = append(alert.Restored, rule)
+		}
+	}
+	slices.Sort(alert.Restored)
+	if len(current) == 0 {
+		delete(auditStates, key)
+	} else {
+		auditStates[key] = current
+	}
+	return alert
+}
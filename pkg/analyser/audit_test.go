@@ -0,0 +1,66 @@
+package analyser
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+)
+
+func TestAuditChange(t *testing.T) {
+	hsts := pinger.AuditFailure{Rule: "header Strict-Transport-Security", Actual: "missing"}
+	frame := pinger.AuditFailure{Rule: "header X-Frame-Options", Actual: "missing"}
+	tls := pinger.AuditFailure{Rule: "min tls TLS 1.2", Actual: "accepts TLS 1.0"}
+	type audit struct {
+		failures []pinger.AuditFailure
+		failed   []string
+		restored []string
+	}
+	tests := []struct {
+		name   string
+		audits []audit
+	}{
+		{name: "first audit counts failures as regressions", audits: []audit{
+			{failures: []pinger.AuditFailure{hsts, tls}, failed: []string{hsts.Rule, tls.Rule}},
+			{failures: []pinger.AuditFailure{hsts, tls}},
+		}},
+		{name: "new failures and fixed rules", audits: []audit{
+			{failures: []pinger.AuditFailure{hsts}, failed: []string{hsts.Rule}},
+			{failures: []pinger.AuditFailure{hsts, frame}, failed: []string{frame.Rule}},
+			{failures: []pinger.AuditFailure{frame}, restored: []string{hsts.Rule}},
+			{restored: []string{frame.Rule}},
+			{},
+		}},
+		{name: "regressed and restored together", audits: []audit{
+			{failures: []pinger.AuditFailure{hsts, frame}, failed: []string{hsts.Rule, frame.Rule}},
+			{failures: []pinger.AuditFailure{tls}, failed: []string{tls.Rule}, restored: []string{hsts.Rule, frame.Rule}},
+		}},
+		{name: "clean from the start", audits: []audit{{}, {}}},
+	}
+	for _, tt := range tests {
+		auditStates = make(map[string]map[string]bool)
+		for i, a := range tt.audits {
+			alert := auditChange("https://www.example.com/", a.failures)
+			var failed []string
+			for _, failure := range alert.Failed {
+				failed = append(failed, failure.Rule)
+			}
+			if !slices.Equal(failed, a.failed) || !slices.Equal(alert.Restored, a.restored) {
+				t.Errorf("%s: audit %d got failed %q restored %q, want %q %q", tt.name, i, failed, alert.Restored, a.failed, a.restored)
+			}
+		}
+		if len(tt.audits[len(tt.audits)-1].failures) == 0 && len(auditStates) != 0 {
+			t.Errorf("%s: a clean audit left state behind", tt.name)
+		}
+	}
+}
+
+func TestAnalyseAuditSkipsUnaudited(t *testing.T) {
+	auditStates = make(map[string]map[string]bool)
+	res := pinger.PingResult{URL: "https://www.example.com/", Status: -1,
+		AuditFailures: []pinger.AuditFailure{{Rule: "header X-Frame-Options", Actual: "missing"}}}
+	if got := events(t, func(finish context.Context) { analyseAudit(res, finish) }); len(got) != 0 {
+		t.Errorf("a result without an audit raised %q", got)
+	}
+}
@@ -0,0 +1,41 @@
+package analyser
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type AuthAlert struct {
+	Url   string
+	Error string
+}
+
+// authFailing tracks targets whose credentials or token endpoint are failing, kept apart from outages
+var authFailing = make(map[string]bool)
+var authMu sync.Mutex
+
+// analyseAuth reports when a target starts and stops failing to authenticate, returning true
+// if the result carries no ping to analyse
+func analyseAuth(res pinger.PingResult, finish context.Context) bool {
+	authMu.Lock()
+	wasFailing := authFailing[res.Key()]
+	authFailing[res.Key()] = res.AuthError != ""
+	authMu.Unlock()
+
+	if res.AuthError == "" {
+		if wasFailing {
+			logger.Log.Info("Auth recovered", zap.String("URL", res.Key()))
+		}
+		return false
+	}
+	if !wasFailing {
+		alert := AuthAlert{Url: res.Key(), Error: res.AuthError}
+		logger.Log.Error("Auth failing", zap.Any("auth", alert))
+		emit("Authentication failing", alert, finish)
+	}
+	return true
+}
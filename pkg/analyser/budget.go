@@ -0,0 +1,70 @@
+package analyser
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type BudgetAlert struct {
+	Url           string
+	DegradedStart time.Time
+	Violations    []string
+}
+
+// budgetState counts results over budget in a row, a target is degraded once it reaches the fail threshold
+type budgetState struct {
+	over          int
+	degradedStart time.Time
+}
+
+var budgetStates = make(map[string]*budgetState)
+var budgetMu sync.Mutex
+
+// analyseBudget reports targets that keep going over their performance budget and when they are back
+// within it. Failed results are left to the outage logic
+func analyseBudget(res pinger.PingResult, finish context.Context) {
+	if res.Failed() {
+		return
+	}
+	budgetMu.Lock()
+	state, ok := budgetStates[res.Key()]
+	if !ok {
+		if len(res.BudgetViolations) == 0 {
+			budgetMu.Unlock()
+			return
+		}
+		state = &budgetState{}
+		budgetStates[res.Key()] = state
+	}
+	var message string
+	if len(res.BudgetViolations) > 0 {
+		state.over++
+		if state.over == defaultFailThreshold {
+			state.degradedStart = res.TimestampUTC
+			message = "Performance degraded"
+		}
+	} else if !state.degradedStart.IsZero() {
+		message = "Performance recovered"
+	}
+	alert := BudgetAlert{Url: res.Key(), DegradedStart: state.degradedStart, Violations: res.BudgetViolations}
+	if len(res.BudgetViolations) == 0 {
+		state.over = 0
+		state.degradedStart = time.Time{}
+	}
+	budgetMu.Unlock()
+
+	if message == "" {
+		return
+	}
+	if len(res.BudgetViolations) > 0 {
+		logger.Log.Warn(message, zap.Any("budget", alert))
+	} else {
+		logger.Log.Info(message, zap.Any("budget", alert))
+	}
+	emit(message, alert, finish)
+}
@@ -0,0 +1,62 @@
+package analyser
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+)
+
+func TestAnalyseBudget(t *testing.T) {
+	over := []string{"latency_ms 950 > 800"}
+	type ping struct {
+		status     int
+		violations []string
+		want       []string
+	}
+	tests := []struct {
+		name  string
+		pings []ping
+	}{
+		{name: "degraded after three in a row then recovered", pings: []ping{
+			{status: 200, violations: over},
+			{status: 200, violations: over},
+			{status: 200, violations: over, want: []string{"Performance degraded"}},
+			{status: 200, violations: over},
+			{status: 200, want: []string{"Performance recovered"}},
+			{status: 200},
+		}},
+		{name: "a result within budget resets the count", pings: []ping{
+			{status: 200, violations: over},
+			{status: 200, violations: over},
+			{status: 200},
+			{status: 200, violations: over},
+			{status: 200, violations: over},
+		}},
+		{name: "failed results are left to the outage logic", pings: []ping{
+			{status: 200, violations: over},
+			{status: 200, violations: over},
+			{status: -1, violations: over},
+			{status: 500},
+			{status: 200, violations: over, want: []string{"Performance degraded"}},
+		}},
+		{name: "within budget from the start", pings: []ping{
+			{status: 200},
+			{status: 200},
+		}},
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		budgetStates = make(map[string]*budgetState)
+		for i, p := range tt.pings {
+			res := pinger.PingResult{URL: "https://www.example.com/", Status: p.status, BudgetViolations: p.violations,
+				TimestampUTC: start.Add(time.Duration(i) * time.Minute)}
+			got := events(t, func(finish context.Context) { analyseBudget(res, finish) })
+			if !slices.Equal(got, p.want) {
+				t.Errorf("%s: ping %d got %q, want %q", tt.name, i, got, p.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package analyser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/notification"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type CertificateAlert struct {
+	Url           string
+	Subject       string
+	Issuer        string
+	Expiry        time.Time
+	DaysRemaining int
+	Error         string
+}
+
+// certState remembers what was already reported for a url, so each threshold fires once per certificate
+type certState struct {
+	expiry  time.Time
+	level   int //number of warning thresholds crossed, one more once expired
+	invalid bool
+}
+
+var certStates = make(map[string]*certState)
+var certMu sync.Mutex
+
+func analyseCertificate(res pinger.PingResult, finish context.Context) {
+	if res.TLS == nil || len(res.TLS.Chain) == 0 {
+		return
+	}
+	leaf := res.TLS.Chain[0]
+	alert := CertificateAlert{
+		Url:           res.Key(),
+		Subject:       leaf.Subject,
+		Issuer:        leaf.Issuer,
+		Expiry:        res.TLS.Expiry,
+		DaysRemaining: int(res.TLS.Expiry.Sub(res.TimestampUTC).Hours() / 24),
+		Error:         res.TLS.VerifyError,
+	}
+
+	certMu.Lock()
+	state, ok := certStates[res.Key()]
+	if !ok {
+		state = &certState{}
+		certStates[res.Key()] = state
+	}
+	warnedLevel := 0
+	if res.TLS.Expiry.After(state.expiry) { //new or renewed certificate
+		state.expiry = res.TLS.Expiry
+		warnedLevel = state.level
+		state.level = 0
+	}
+	level := expiryLevel(res.TLS.Expiry, res.TimestampUTC)
+	renewed := warnedLevel > level //an expiry warning was sent for the certificate this one replaced
+	expiryWarning := level > state.level
+	if expiryWarning {
+		state.level = level
+	}
+	becameInvalid := alert.Error != "" && !state.invalid
+	becameValid := alert.Error == "" && state.invalid
+	state.invalid = alert.Error != ""
+	certMu.Unlock()
+
+	if becameValid {
+		logger.Log.Info("Certificate valid again", zap.Any("certificate", alert))
+		emit("Certificate valid again", alert, finish)
+	}
+	if renewed {
+		logger.Log.Info("Certificate renewed", zap.Any("certificate", alert))
+		emit("Certificate renewed", alert, finish)
+	}
+	if becameInvalid {
+		logger.Log.Error("Certificate invalid", zap.Any("certificate", alert))
+		emit("Certificate invalid: "+alert.Error, alert, finish)
+	}
+	if expiryWarning {
+		message := fmt.Sprintf("Certificate expires in %d days", alert.DaysRemaining)
+		if !res.TLS.Expiry.After(res.TimestampUTC) {
+			message = "Certificate expired"
+		}
+		logger.Log.Warn(message, zap.Any("certificate", alert))
+		emit(message, alert, finish)
+	}
+}
+
+// expiryLevel counts the configured thresholds already crossed, an expired certificate crosses all of them and one more
+func expiryLevel(expiry, now time.Time) int {
+	if !expiry.After(now) {
+		return len(config.ProdConfig.CertExpiryWarningDays) + 1
+	}
+	level := 0
+	remaining := expiry.Sub(now)
+	for _, days := range config.ProdConfig.CertExpiryWarningDays {
+		if remaining <= time.Duration(days)*24*time.Hour {
+			level++
+		}
+	}
+	return level
+}
+
+// emit writes an event to the notification channel unless the monitor is shutting down
+func emit(message string, data notification.Notifiable, finish context.Context) {
+	notif := notification.Event{Message: message, Data: data, TimestampUTC: time.Now()}
+	select {
+	case <-finish.Done():
+	case notification.EventChannel <- notif:
+		//safe enqueue
+	}
+}
@@ -0,0 +1,81 @@
+package analyser
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+)
+
+func TestAnalyseCertificate(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := start.Add(40 * day)
+	renewed := start.Add(100 * day)
+	type ping struct {
+		at          time.Duration //since start
+		expiry      time.Time
+		verifyError string
+		want        []string
+	}
+	tests := []struct {
+		name  string
+		pings []ping
+	}{
+		{name: "crossing every threshold", pings: []ping{
+			{at: 0, expiry: first},
+			{at: 11 * day, expiry: first, want: []string{"Certificate expires in 29 days"}},
+			{at: 12 * day, expiry: first},
+			{at: 27 * day, expiry: first, want: []string{"Certificate expires in 13 days"}},
+			{at: 30 * day, expiry: first},
+			{at: 34 * day, expiry: first, want: []string{"Certificate expires in 6 days"}},
+			{at: 41 * day, expiry: first, want: []string{"Certificate expired"}},
+			{at: 42 * day, expiry: first},
+		}},
+		{name: "renewed after a warning", pings: []ping{
+			{at: 30 * day, expiry: first, want: []string{"Certificate expires in 10 days"}},
+			{at: 31 * day, expiry: renewed, want: []string{"Certificate renewed"}},
+			{at: 32 * day, expiry: renewed},
+		}},
+		{name: "renewed before any warning", pings: []ping{
+			{at: 0, expiry: first},
+			{at: day, expiry: renewed},
+		}},
+		{name: "renewed after expiring", pings: []ping{
+			{at: 41 * day, expiry: first, want: []string{"Certificate expired"}},
+			{at: 42 * day, expiry: renewed, want: []string{"Certificate renewed"}},
+		}},
+		{name: "invalid then valid", pings: []ping{
+			{at: 0, expiry: first, verifyError: "x509: certificate signed by unknown authority",
+				want: []string{"Certificate invalid: x509: certificate signed by unknown authority"}},
+			{at: day, expiry: first, verifyError: "x509: certificate signed by unknown authority"},
+			{at: 2 * day, expiry: first, want: []string{"Certificate valid again"}},
+			{at: 3 * day, expiry: first},
+		}},
+		{name: "restart inside a window warns once for it", pings: []ping{
+			{at: 31 * day, expiry: first, want: []string{"Certificate expires in 9 days"}},
+			{at: 32 * day, expiry: first},
+			{at: 34 * day, expiry: first, want: []string{"Certificate expires in 6 days"}},
+		}},
+	}
+	config.ProdConfig.CertExpiryWarningDays = []int{30, 14, 7}
+	for _, tt := range tests {
+		certStates = make(map[string]*certState)
+		for i, p := range tt.pings {
+			res := pinger.PingResult{
+				URL:          "https://www.example.com/",
+				Status:       200,
+				TimestampUTC: start.Add(p.at),
+				TLS: &pinger.TLSInfo{Expiry: p.expiry, VerifyError: p.verifyError,
+					Chain: []pinger.CertInfo{{Subject: "CN=www.example.com", Issuer: "CN=Example CA", NotAfter: p.expiry}}},
+			}
+			got := events(t, func(finish context.Context) { analyseCertificate(res, finish) })
+			if !slices.Equal(got, p.want) {
+				t.Errorf("%s: ping %d got %q, want %q", tt.name, i, got, p.want)
+			}
+		}
+	}
+}
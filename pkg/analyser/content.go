@@ -0,0 +1,168 @@
+package analyser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+// lines of each side shown in a change notification
+const maxDiffLines = 10
+
+type ContentChange struct {
+	Url          string
+	PreviousHash string
+	Hash         string
+	LinesAdded   int
+	LinesRemoved int
+	Diff         string
+}
+
+// baseline is the last seen content of a tracked target, stored as json under output_dir/baselines
+type baseline struct {
+	URL     string    `json:"url"`
+	Hash    string    `json:"hash"`
+	Updated time.Time `json:"updated"`
+	Content string    `json:"content"`
+}
+
+var baselines = make(map[string]*baseline)
+var baselineDir string
+var baselineMu sync.Mutex
+
+// LoadBaselines reads the content baselines kept from earlier runs
+func LoadBaselines(outputDir string) error {
+	baselineDir = path.Join(outputDir, "baselines")
+	if err := os.MkdirAll(baselineDir, 0755); err != nil {
+		return fmt.Errorf("cannot create baseline dir: %w", err)
+	}
+	entries, err := os.ReadDir(baselineDir)
+	if err != nil {
+		return fmt.Errorf("cannot read baseline dir: %w", err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(path.Join(baselineDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("cannot read baseline: %w", err)
+		}
+		var b baseline
+		if err := json.Unmarshal(data, &b); err != nil {
+			logger.Log.Warn("Skipping unreadable baseline", zap.String("file", entry.Name()), zap.Error(err))
+			continue
+		}
+		baselines[b.URL] = &b
+	}
+	return nil
+}
+
+func baselineFile(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return path.Join(baselineDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func saveBaseline(b *baseline) {
+	data, err := json.Marshal(b)
+	if err == nil {
+		err = os.WriteFile(baselineFile(b.URL), data, 0644)
+	}
+	if err != nil {
+		logger.Log.Error("Baseline write error", zap.String("URL", b.URL), zap.Error(err))
+	}
+}
+
+// analyseContent compares a tracked target's content with its baseline, the first result only sets the
+// baseline and every change moves it forward so each change is reported once
+func analyseContent(res pinger.PingResult, finish context.Context) {
+	if res.ContentHash == "" || res.Failed() {
+		return
+	}
+	current := &baseline{URL: res.Key(), Hash: res.ContentHash, Updated: res.TimestampUTC, Content: string(res.Content())}
+	baselineMu.Lock()
+	previous, ok := baselines[res.Key()]
+	if ok && previous.Hash == res.ContentHash {
+		baselineMu.Unlock()
+		return
+	}
+	baselines[res.Key()] = current
+	saveBaseline(current)
+	baselineMu.Unlock()
+	if !ok {
+		logger.Log.Info("Content baseline recorded", zap.String("URL", res.Key()), zap.String("hash", res.ContentHash))
+		return
+	}
+
+	added, removed := diffLines(previous.Content, current.Content)
+	change := ContentChange{
+		Url:          res.Key(),
+		PreviousHash: previous.Hash,
+		Hash:         current.Hash,
+		LinesAdded:   len(added),
+		LinesRemoved: len(removed),
+		Diff:         diffSummary(added, removed),
+	}
+	logger.Log.Warn("Content changed", zap.Any("change", change))
+	emit("Content changed", change, finish)
+}
+
+// diffLines returns the lines only found in the new content and those only found in the old one,
+// counting repeated lines, which is enough to summarise a change without a full diff
+func diffLines(before, after string) ([]string, []string) {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(before, "\n") {
+		counts[line]++
+	}
+	var added []string
+	for _, line := range strings.Split(after, "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+			continue
+		}
+		added = append(added, line)
+	}
+	var removed []string
+	for _, line := range strings.Split(before, "\n") {
+		if counts[line] > 0 {
+			counts[line]--
+			removed = append(removed, line)
+		}
+	}
+	return added, removed
+}
+
+func diffSummary(added, removed []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "+%d -%d lines\n", len(added), len(removed))
+	for i, line := range removed {
+		if i == maxDiffLines {
+			fmt.Fprintf(&b, "- ... %d more\n", len(removed)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", truncateLine(line))
+	}
+	for i, line := range added {
+		if i == maxDiffLines {
+			fmt.Fprintf(&b, "+ ... %d more\n", len(added)-i)
+			break
+		}
+		fmt.Fprintf(&b, "+ %s\n", truncateLine(line))
+	}
+	return b.String()
+}
+
+func truncateLine(line string) string {
+	if len(line) > 200 {
+		return line[:200] + "..."
+	}
+	return line
+}
@@ -0,0 +1,28 @@
+package analyser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/notification"
+	"go.uber.org/zap"
+)
+
+// events runs analyse and returns the messages of the events it emitted
+func events(t *testing.T, analyse func(finish context.Context)) []string {
+	t.Helper()
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	analyse(context.Background())
+	var messages []string
+	for {
+		select {
+		case event := <-notification.EventChannel:
+			messages = append(messages, event.Message)
+		default:
+			return messages
+		}
+	}
+}
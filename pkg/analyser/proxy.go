@@ -0,0 +1,42 @@
+package analyser
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type ProxyAlert struct {
+	Url   string
+	Proxy string
+	Error string
+}
+
+// proxyFailing tracks targets whose proxy is failing, the target itself was not reached so it is kept apart from outages
+var proxyFailing = make(map[string]bool)
+var proxyMu sync.Mutex
+
+// analyseProxy reports when a target's proxy starts and stops failing, returning true if the
+// result carries no ping to analyse
+func analyseProxy(res pinger.PingResult, finish context.Context) bool {
+	proxyMu.Lock()
+	wasFailing := proxyFailing[res.Key()]
+	proxyFailing[res.Key()] = res.ProxyError != ""
+	proxyMu.Unlock()
+
+	if res.ProxyError == "" {
+		if wasFailing {
+			logger.Log.Info("Proxy recovered", zap.String("URL", res.Key()), zap.String("proxy", res.Proxy))
+		}
+		return false
+	}
+	if !wasFailing {
+		alert := ProxyAlert{Url: res.Key(), Proxy: res.Proxy, Error: res.ProxyError}
+		logger.Log.Error("Proxy failing", zap.Any("proxy", alert))
+		emit("Proxy failing", alert, finish)
+	}
+	return true
+}
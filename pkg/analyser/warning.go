@@ -0,0 +1,40 @@
+package analyser
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+type CheckWarning struct {
+	Url        string
+	StatusLine string
+	Perfdata   []pinger.Perfdata
+}
+
+// warning tracks exec checks in the WARNING state, which is reported without counting as an outage
+var warning = make(map[string]bool)
+var warningMu sync.Mutex
+
+func analyseWarning(res pinger.PingResult, finish context.Context) {
+	if res.CheckState == "" {
+		return
+	}
+	warningMu.Lock()
+	wasWarning := warning[res.Key()]
+	warning[res.Key()] = res.CheckState == "WARNING"
+	warningMu.Unlock()
+
+	alert := CheckWarning{Url: res.Key(), StatusLine: res.StatusLine, Perfdata: res.Perfdata}
+	switch {
+	case res.CheckState == "WARNING" && !wasWarning:
+		logger.Log.Warn("Check warning", zap.Any("warning", alert))
+		emit("Check warning", alert, finish)
+	case res.CheckState == "OK" && wasWarning:
+		logger.Log.Info("Check warning cleared", zap.Any("warning", alert))
+		emit("Check warning cleared", alert, finish)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/jsonpath"
+)
+
+// Checks are the response body assertions, shared by targets and transaction steps
+type Checks struct {
+	Assertions     []Assertion `json:"assertions,omitempty"`
+	JSONAssertions []string    `json:"json_assertions,omitempty"`
+	jsonExprs      []*jsonpath.Expr
+}
+
+// Assertion is a check run against the response body, types are contains, not_contains, regex and not_regex
+type Assertion struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	pattern *regexp.Regexp
+}
+
+// Pattern returns the compiled regex for regex assertions, nil for the rest
+func (a Assertion) Pattern() *regexp.Regexp {
+	return a.pattern
+}
+
+func (a Assertion) String() string {
+	return fmt.Sprintf("%s %q", a.Type, a.Value)
+}
+
+// JSONExprs returns the parsed json assertions
+func (c Checks) JSONExprs() []*jsonpath.Expr {
+	return c.jsonExprs
+}
+
+// Empty reports whether there is nothing to check in the body
+func (c Checks) Empty() bool {
+	return len(c.Assertions) == 0 && len(c.jsonExprs) == 0
+}
+
+// validate compiles the regex and json assertions
+func (c *Checks) validate() error {
+	var err error
+	for i := range c.Assertions {
+		a := &c.Assertions[i]
+		a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+		switch a.Type {
+		case "contains", "not_contains":
+			if a.Value == "" {
+				return fmt.Errorf("empty value for %s assertion", a.Type)
+			}
+		case "regex", "not_regex":
+			a.pattern, err = regexp.Compile(a.Value)
+			if err != nil {
+				return fmt.Errorf("bad regex assertion: %w", err)
+			}
+		default:
+			return fmt.Errorf("unknown assertion type %q", a.Type)
+		}
+	}
+
+	c.jsonExprs = make([]*jsonpath.Expr, 0, len(c.JSONAssertions))
+	for _, source := range c.JSONAssertions {
+		expr, err := jsonpath.Parse(source)
+		if err != nil {
+			return err
+		}
+		c.jsonExprs = append(c.jsonExprs, expr)
+	}
+	return nil
+}
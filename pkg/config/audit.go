@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Audit is a security policy checked against the response headers and TLS setup of an http target.
+// An empty audit applies the default policy
+type Audit struct {
+	RequireHeaders    map[string]string `json:"require_headers,omitempty"`
+	ForbidHeaders     []string          `json:"forbid_headers,omitempty"`
+	MinTLSVersion     string            `json:"min_tls_version,omitempty"`
+	ForbidWeakCiphers bool              `json:"forbid_weak_ciphers,omitempty"`
+	required          map[string]*regexp.Regexp
+	minVersion        uint16
+}
+
+// Required returns the headers that must be present, with the pattern their value must match, nil when any value will do
+func (a *Audit) Required() map[string]*regexp.Regexp {
+	return a.required
+}
+
+// MinVersion returns the lowest TLS version the target may accept, zero when not audited
+func (a *Audit) MinVersion() uint16 {
+	return a.minVersion
+}
+
+// ProbesTLS reports whether the audit makes its own handshakes with the target
+func (a *Audit) ProbesTLS() bool {
+	return a.minVersion != 0 || a.ForbidWeakCiphers
+}
+
+func (a *Audit) empty() bool {
+	return len(a.RequireHeaders) == 0 && len(a.ForbidHeaders) == 0 && a.MinTLSVersion == "" && !a.ForbidWeakCiphers
+}
+
+// validate fills in the default policy for an empty audit and compiles the header patterns
+func (a *Audit) validate(scheme string) error {
+	if a.empty() {
+		a.RequireHeaders = map[string]string{
+			"X-Content-Type-Options":  "(?i)^nosniff$",
+			"Content-Security-Policy": "",
+		}
+		if scheme == "https" {
+			a.RequireHeaders["Strict-Transport-Security"] = `max-age=\d+`
+			a.MinTLSVersion = "1.2"
+			a.ForbidWeakCiphers = true
+		}
+	}
+	a.required = make(map[string]*regexp.Regexp, len(a.RequireHeaders))
+	for name, pattern := range a.RequireHeaders {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("empty header name in audit")
+		}
+		var re *regexp.Regexp
+		if pattern != "" {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("bad audit pattern for %s: %w", name, err)
+			}
+		}
+		a.required[name] = re
+	}
+	if a.MinTLSVersion != "" || a.ForbidWeakCiphers {
+		if scheme != "https" {
+			return fmt.Errorf("tls audit rules need an https url")
+		}
+	}
+	if a.MinTLSVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(a.MinTLSVersion)]
+		if !ok {
+			return fmt.Errorf("unsupported audit min_tls_version %q", a.MinTLSVersion)
+		}
+		a.minVersion = version
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Auth describes how a target authenticates, types are basic, bearer and oauth2 (client credentials)
+type Auth struct {
+	Type         string   `json:"type"`
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	Token        string   `json:"token,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+func (a *Auth) validate() error {
+	a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+	switch a.Type {
+	case "basic":
+		if a.Username == "" {
+			return fmt.Errorf("basic auth needs a username")
+		}
+	case "bearer":
+		a.Token = strings.TrimSpace(a.Token)
+		if a.Token == "" {
+			return fmt.Errorf("bearer auth needs a token")
+		}
+	case "oauth2":
+		a.TokenURL = strings.TrimSpace(a.TokenURL)
+		parsed, err := url.Parse(a.TokenURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("oauth2 auth needs an http(s) token_url")
+		}
+		if a.ClientID == "" || a.ClientSecret == "" {
+			return fmt.Errorf("oauth2 auth needs client_id and client_secret")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %q", a.Type)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "fmt"
+
+// Budget sets performance limits for an http target, going over them degrades the target without
+// counting as an outage. Zero leaves a limit unset
+type Budget struct {
+	MaxBodyBytes     int64 `json:"max_body_bytes,omitempty"`
+	MaxTransferBytes int64 `json:"max_transfer_bytes,omitempty"`
+	MaxLatencyMS     int64 `json:"max_latency_ms,omitempty"`
+	MaxTTFBMS        int64 `json:"max_ttfb_ms,omitempty"`
+}
+
+func (b *Budget) validate() error {
+	if b.MaxBodyBytes < 0 || b.MaxTransferBytes < 0 || b.MaxLatencyMS < 0 || b.MaxTTFBMS < 0 {
+		return fmt.Errorf("budget limits cannot be negative")
+	}
+	if *b == (Budget{}) {
+		return fmt.Errorf("budget sets no limits")
+	}
+	return nil
+}
@@ -1,31 +1,38 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/mail"
 	"net/url"
 	"os"
-
-	// "slices"
+	"slices"
 	"strings"
 	"time"
 
 )
 
 type Config struct {
-	URLs                  []string `json:"urls"`
-	WorkerCount           int      `json:"worker_count"`
-	RateLimitPerSec       int      `json:"rate_limit_per_sec"`
-	RequestTimeOutSecs    int      `json:"request_timeout_secs"`
-	LogLevel              string   `json:"log_level"`
-	OutputDir             string   `json:"output_dir"`
-	RequestInterval       int      `json:"request_interval"`
-	NotificationServices  []string `json:"notification_services"`
-	DiscordWebhookAddress string   `json:"discord_webhook_address"`
-	MailerSendAPIToken    string   `json:"mailersend_api_token"`
-	MailerSendEmailId     string   `json:"mailersend_email_id"`
-	NotificationMailId    string   `json:"mail_id"`
+	URLs                  []string               `json:"urls"`
+	Targets               []Target               `json:"targets"`
+	WorkerCount           int                    `json:"worker_count"`
+	RateLimitPerSec       int                    `json:"rate_limit_per_sec"`
+	RequestTimeOutSecs    int                    `json:"request_timeout_secs"`
+	LogLevel              string                 `json:"log_level"`
+	OutputDir             string                 `json:"output_dir"`
+	RequestInterval       int                    `json:"request_interval"`
+	NotificationServices  []string               `json:"notification_services"`
+	DiscordWebhookAddress string                 `json:"discord_webhook_address"`
+	MailerSendAPIToken    string                 `json:"mailersend_api_token"`
+	MailerSendEmailId     string                 `json:"mailersend_email_id"`
+	NotificationMailId    string                 `json:"mail_id"`
+	CertExpiryWarningDays []int                  `json:"cert_expiry_warning_days"`
+	TLSProfiles           map[string]*TLSProfile `json:"tls_profiles"`
+	Heartbeats            []Heartbeat            `json:"heartbeats"`
+	HeartbeatListen       string                 `json:"heartbeat_listen"`
+	Proxy                 string                 `json:"proxy"`
+	proxyURL              *url.URL
 }
 
 var ProdConfig Config = Config{}
@@ -41,6 +48,9 @@ func Load(path string) error {
 		defaultInterval = 10
 		minRatePerSec   = 1
 		maxRatePerSec   = 10
+
+		//check-ins are only taken from the host itself unless a listen address is given
+		defaultHeartbeatListen = "127.0.0.1:8080"
 	)
 
 	data, err := os.ReadFile(path)
@@ -51,38 +61,78 @@ func Load(path string) error {
 		return fmt.Errorf("malformed config: %w", err)
 	}
 
-	if len(ProdConfig.URLs) == 0 {
+	if len(ProdConfig.URLs) == 0 && len(ProdConfig.Targets) == 0 && len(ProdConfig.Heartbeats) == 0 {
 		return fmt.Errorf("no URLs provided in config")
 	}
 
-	urlmap := make(map[string]struct{}) //handling duplicate urls
-	cleanedURLs := make([]string, 0, len(ProdConfig.URLs))
+	//plain urls are targets without any options
+	targets := make([]Target, 0, len(ProdConfig.URLs)+len(ProdConfig.Targets))
+	for _, u := range ProdConfig.URLs {
+		targets = append(targets, Target{URL: u})
+	}
+	targets = append(targets, ProdConfig.Targets...)
+
+	ProdConfig.proxyURL, err = parseProxy(ProdConfig.Proxy)
+	if err != nil {
+		return fmt.Errorf("proxy: %w", err)
+	}
+
+	urlmap := make(map[string]int) //handling duplicate urls, index of the first target with each url
+	cleanedTargets := make([]Target, 0, len(targets))
+	cleanedURLs := make([]string, 0, len(targets))
 
-	for i, u := range ProdConfig.URLs {
-		u = strings.TrimSpace(u)
-		if u == "" {
+	for i, t := range targets {
+		if strings.TrimSpace(t.URL) == "" {
 			fmt.Printf("Skipping empty URL at index %d\n", i)
 			continue
 		}
-		parsed, err := url.Parse(u)
-		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
-			return fmt.Errorf("invalid URL at index %d: %q", i, u)
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("target at index %d: %w", i, err)
 		}
-		if parsed.Scheme != "http" && parsed.Scheme != "https" {
-			return fmt.Errorf("unsupported URL scheme at index %d: %q", i, u)
+		if err := resolveTLSProfile(&t, ProdConfig.TLSProfiles); err != nil {
+			return fmt.Errorf("target at index %d: %w", i, err)
 		}
-		_, duplicate := urlmap[parsed.String()] //duplicate handling
+		if err := resolveProxy(&t, ProdConfig.proxyURL); err != nil {
+			return fmt.Errorf("target at index %d: %w", i, err)
+		}
+		first, duplicate := urlmap[t.URL] //duplicate handling
 		if duplicate {
-			fmt.Printf("Skipping duplicate URL %s\n", parsed.String())
+			//results are tracked by url, so targets sharing one must be the same check
+			if !sameTarget(t, cleanedTargets[first]) {
+				return fmt.Errorf("target at index %d: %q is already a target with different options, add a #fragment to tell them apart", i, t.URL)
+			}
+			fmt.Printf("Skipping duplicate URL %s\n", t.URL)
 		} else {
-			urlmap[parsed.String()] = struct{}{}
-			cleanedURLs = append(cleanedURLs, parsed.String())
+			urlmap[t.URL] = len(cleanedTargets)
+			cleanedTargets = append(cleanedTargets, t)
+			cleanedURLs = append(cleanedURLs, t.URL)
 		}
 	}
-	if len(cleanedURLs) == 0 {
+	ProdConfig.Targets = cleanedTargets
+	ProdConfig.URLs = cleanedURLs
+	if ProdConfig.proxyURL != nil {
+		warnUnproxied(cleanedTargets)
+	}
+
+	heartbeatIDs := make(map[string]struct{})
+	for i := range ProdConfig.Heartbeats {
+		h := &ProdConfig.Heartbeats[i]
+		if err := h.validate(); err != nil {
+			return fmt.Errorf("heartbeat at index %d: %w", i, err)
+		}
+		if _, duplicate := heartbeatIDs[h.ID]; duplicate {
+			return fmt.Errorf("duplicate heartbeat id %q", h.ID)
+		}
+		heartbeatIDs[h.ID] = struct{}{}
+	}
+	if len(ProdConfig.Heartbeats) > 0 && strings.TrimSpace(ProdConfig.HeartbeatListen) == "" {
+		ProdConfig.HeartbeatListen = defaultHeartbeatListen
+		fmt.Println("Heartbeat listen address not specified, defaulting to " + defaultHeartbeatListen)
+	}
+	warnOpenHeartbeats(ProdConfig.HeartbeatListen, ProdConfig.Heartbeats)
+	if len(cleanedTargets) == 0 && len(ProdConfig.Heartbeats) == 0 {
 		return fmt.Errorf("no Valid URLs to monitor")
 	}
-	ProdConfig.URLs = cleanedURLs
 
 	// Worker count
 	if ProdConfig.WorkerCount < minWorkers {
@@ -138,6 +188,22 @@ func Load(path string) error {
 		fmt.Println("Unrecognized log level, defaulting to info")
 	}
 
+	// Certificate expiry warnings, kept in descending order of days
+	if len(ProdConfig.CertExpiryWarningDays) == 0 {
+		ProdConfig.CertExpiryWarningDays = []int{30, 14, 7, 1}
+	}
+	cleanedDays := make([]int, 0, len(ProdConfig.CertExpiryWarningDays))
+	for _, days := range ProdConfig.CertExpiryWarningDays {
+		if days < 1 {
+			fmt.Printf("Ignoring certificate warning threshold %d, must be at least 1 day\n", days)
+			continue
+		}
+		cleanedDays = append(cleanedDays, days)
+	}
+	slices.Sort(cleanedDays)
+	slices.Reverse(cleanedDays)
+	ProdConfig.CertExpiryWarningDays = slices.Compact(cleanedDays)
+
 	serviceNames := map[string]struct{}{"discord":{},"email":{}}
 	cleanedSenders := make([]string, 0, len(serviceNames))
 	//load notification integrations
@@ -190,6 +256,14 @@ func Load(path string) error {
 
 	return nil
 }
+
+// sameTarget reports whether two targets are configured identically
+func sameTarget(a, b Target) bool {
+	ea, errA := json.Marshal(a)
+	eb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ea, eb)
+}
+
 func (c *Config) GetRequestIntervalDuration() time.Duration {
 	return time.Duration(c.RequestInterval) * time.Second
 }
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ContentTracking opts a target into change detection. Matches of the ignore patterns are
+// removed before hashing, normalize also collapses whitespace and drops blank lines
+type ContentTracking struct {
+	Normalize      bool     `json:"normalize,omitempty"`
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+	ignore         []*regexp.Regexp
+}
+
+// Ignore returns the compiled ignore patterns
+func (c *ContentTracking) Ignore() []*regexp.Regexp {
+	return c.ignore
+}
+
+func (c *ContentTracking) validate() error {
+	c.ignore = make([]*regexp.Regexp, 0, len(c.IgnorePatterns))
+	for _, pattern := range c.IgnorePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("bad ignore pattern: %w", err)
+		}
+		c.ignore = append(c.ignore, re)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Heartbeat is a push monitor, the job checks in at /heartbeat/<id> at least every period. With a token
+// set, check-ins must present it so nobody else can keep a dead job looking alive
+type Heartbeat struct {
+	ID         string `json:"id"`
+	PeriodSecs int    `json:"period_secs"`
+	GraceSecs  int    `json:"grace_secs"`
+	Token      string `json:"token,omitempty"`
+}
+
+var heartbeatID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// URL is the name heartbeat results are reported under
+func (h Heartbeat) URL() string {
+	return "heartbeat://" + h.ID
+}
+
+func (h Heartbeat) Period() time.Duration {
+	return time.Duration(h.PeriodSecs) * time.Second
+}
+
+func (h Heartbeat) Grace() time.Duration {
+	return time.Duration(h.GraceSecs) * time.Second
+}
+
+func (h *Heartbeat) validate() error {
+	if !heartbeatID.MatchString(h.ID) {
+		return fmt.Errorf("heartbeat id %q must be letters, digits, - or _", h.ID)
+	}
+	if h.PeriodSecs < 1 {
+		return fmt.Errorf("heartbeat %q needs a period_secs of at least 1", h.ID)
+	}
+	if h.GraceSecs < 0 {
+		return fmt.Errorf("heartbeat %q has a negative grace_secs", h.ID)
+	}
+	return nil
+}
+
+// warnOpenHeartbeats points out heartbeats anyone can check in to, those without a token on a listener
+// that is reachable from other hosts
+func warnOpenHeartbeats(listen string, heartbeats []Heartbeat) {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return
+	}
+	var open []string
+	for _, h := range heartbeats {
+		if h.Token == "" {
+			open = append(open, h.ID)
+		}
+	}
+	if len(open) > 0 {
+		fmt.Printf("Heartbeat listener %s is reachable from other hosts, heartbeats without a token accept check-ins from anyone: %s\n",
+			listen, strings.Join(open, ", "))
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// the target proxy setting that bypasses the global proxy
+const directProxy = "direct"
+
+// ProxyURL returns the proxy the target's http requests go through, nil when they connect directly
+func (t Target) ProxyURL() *url.URL {
+	return t.proxyURL
+}
+
+// ProxyURL returns the global proxy, nil when none is set
+func (c Config) ProxyURL() *url.URL {
+	return c.proxyURL
+}
+
+// parseProxy accepts http, https, socks5 and socks5h proxy urls, credentials go in the userinfo
+func parseProxy(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(raw)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url")
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use http, https, socks5 or socks5h", proxy.Scheme)
+	}
+	return proxy, nil
+}
+
+// resolveProxy picks the target's own proxy or the global one, only http based probes go through proxies
+func resolveProxy(t *Target, global *url.URL) error {
+	t.Proxy = strings.TrimSpace(t.Proxy)
+	if !t.proxiable() {
+		if t.Proxy != "" {
+			return fmt.Errorf("target %q: proxy only applies to http, https, transaction and script targets", t.URL)
+		}
+		return nil
+	}
+	switch t.Proxy {
+	case "":
+		t.proxyURL = global
+	case directProxy:
+		t.proxyURL = nil
+	default:
+		proxy, err := parseProxy(t.Proxy)
+		if err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+		t.proxyURL = proxy
+	}
+	if t.proxyURL == nil {
+		return nil
+	}
+	if len(t.Resolve) > 0 || t.AddressFamily != "" || slices.Contains(t.Protocols, "http3") {
+		return fmt.Errorf("target %q: resolve, address_family and http3 need a direct connection, set proxy to %q", t.URL, directProxy)
+	}
+	if t.Audit != nil && t.Audit.ProbesTLS() {
+		return fmt.Errorf("target %q: tls audit rules need a direct connection, set proxy to %q", t.URL, directProxy)
+	}
+	return nil
+}
+
+// proxiable reports whether the target's checks can go through a proxy, the other schemes always dial directly
+func (t Target) proxiable() bool {
+	switch t.Scheme() {
+	case "http", "https", "transaction", "script":
+		return true
+	}
+	return false
+}
+
+// warnUnproxied points out the targets that bypass the global proxy, so a monitor meant to check from
+// behind it does not silently check some targets from its own network
+func warnUnproxied(targets []Target) {
+	var direct []string
+	for _, t := range targets {
+		if !t.proxiable() {
+			direct = append(direct, t.URL)
+		}
+	}
+	if len(direct) > 0 {
+		fmt.Printf("Proxy does not apply to %d targets, they connect directly: %s\n", len(direct), strings.Join(direct, ", "))
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// replaces secrets in the config view that gets logged
+const redacted = "REDACTED"
+
+// headers whose values are credentials
+var secretHeaders = []string{"Authorization", "Cookie", "X-Api-Key"}
+
+// Redacted returns a copy of the config that is safe to log, with credentials, tokens, webhook
+// addresses, request bodies and url query values replaced
+func (c Config) Redacted() Config {
+	c.MailerSendAPIToken = redact(c.MailerSendAPIToken)
+	c.DiscordWebhookAddress = redact(c.DiscordWebhookAddress)
+	c.Proxy = redactProxy(c.Proxy)
+	c.Heartbeats = slices.Clone(c.Heartbeats)
+	for i := range c.Heartbeats {
+		c.Heartbeats[i].Token = redact(c.Heartbeats[i].Token)
+	}
+	c.Targets = slices.Clone(c.Targets)
+	for i := range c.Targets {
+		t := &c.Targets[i]
+		t.URL = redactURL(t.URL)
+		t.Auth = t.Auth.redacted()
+		t.Proxy = redactProxy(t.Proxy)
+		t.Request = t.Request.redacted()
+		t.Steps = slices.Clone(t.Steps)
+		for j := range t.Steps {
+			t.Steps[j].URL = redactURL(t.Steps[j].URL)
+			t.Steps[j].Request = t.Steps[j].Request.redacted()
+		}
+	}
+	return c
+}
+
+func (a *Auth) redacted() *Auth {
+	if a == nil {
+		return nil
+	}
+	copied := *a
+	copied.Password = redact(a.Password)
+	copied.Token = redact(a.Token)
+	copied.ClientSecret = redact(a.ClientSecret)
+	return &copied
+}
+
+func (r Request) redacted() Request {
+	//bodies carry login forms and api payloads, so they are hidden whole
+	r.Body = redact(r.Body)
+	if len(r.payload) > 0 {
+		r.payload = []byte(redacted)
+	}
+	if r.Headers == nil {
+		return r
+	}
+	r.Headers = maps.Clone(r.Headers)
+	for name, value := range r.Headers {
+		for _, secret := range secretHeaders {
+			if http.CanonicalHeaderKey(name) == secret {
+				r.Headers[name] = redact(value)
+			}
+		}
+	}
+	return r
+}
+
+// redactURL hides the password and every query value of a url, keeping the parameter names. Urls
+// with {{variables}} may not parse, so the query is masked on the raw text
+func redactURL(raw string) string {
+	if parsed, err := url.Parse(raw); err == nil {
+		raw = parsed.Redacted()
+	}
+	base, rest, ok := strings.Cut(raw, "?")
+	if !ok {
+		return raw
+	}
+	query, fragment, hasFragment := strings.Cut(rest, "#")
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		if name, _, ok := strings.Cut(param, "="); ok {
+			params[i] = name + "=" + redacted
+		}
+	}
+	raw = base + "?" + strings.Join(params, "&")
+	if hasFragment {
+		raw += "#" + fragment
+	}
+	return raw
+}
+
+// redactProxy hides the password in a proxy url, a url that does not parse is hidden whole
+func redactProxy(raw string) string {
+	if raw == "" || raw == directProxy {
+		return raw
+	}
+	proxy, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return redacted
+	}
+	return proxy.Redacted()
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// go's own http.Client limit, used when a policy follows redirects without max_hops
+const DefaultMaxRedirects = 10
+
+// RedirectPolicy controls how a target's redirects are followed, policy is follow (default) or none
+type RedirectPolicy struct {
+	Policy       string   `json:"policy,omitempty"`
+	MaxHops      int      `json:"max_hops,omitempty"`
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+}
+
+// AllowsHost reports whether a redirect may go to the host, entries like *.example.com match subdomains
+func (r RedirectPolicy) AllowsHost(host string) bool {
+	if len(r.AllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range r.AllowedHosts {
+		if host == allowed {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RedirectPolicy) validate(targetHost string) error {
+	r.Policy = strings.ToLower(strings.TrimSpace(r.Policy))
+	switch r.Policy {
+	case "", "follow":
+		r.Policy = "follow"
+	case "none":
+	default:
+		return fmt.Errorf("unknown redirect policy %q", r.Policy)
+	}
+	if r.MaxHops < 0 {
+		return fmt.Errorf("max_hops cannot be negative")
+	}
+	if r.MaxHops == 0 {
+		r.MaxHops = DefaultMaxRedirects
+	}
+	for i, host := range r.AllowedHosts {
+		r.AllowedHosts[i] = strings.ToLower(strings.TrimSpace(host))
+	}
+	//the target's own host is always allowed
+	if len(r.AllowedHosts) > 0 && !r.AllowsHost(targetHost) {
+		r.AllowedHosts = append(r.AllowedHosts, strings.ToLower(targetHost))
+	}
+	return nil
+}
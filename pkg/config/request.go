@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Request holds the http options of a target, an empty request is a plain GET
+type Request struct {
+	Method         string            `json:"method,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	BodyFile       string            `json:"body_file,omitempty"`
+	ExpectedStatus []int             `json:"expected_status,omitempty"`
+	payload        []byte
+}
+
+// Payload returns the request body, read from body_file when one was given
+func (r Request) Payload() []byte {
+	return r.payload
+}
+
+// WithPayload returns a copy of the request with a different body
+func (r Request) WithPayload(payload []byte) Request {
+	r.payload = payload
+	return r
+}
+
+// validate fills in the method, checks the status codes and loads the body file
+func (r *Request) validate() error {
+	r.Method = strings.ToUpper(strings.TrimSpace(r.Method))
+	if r.Method == "" {
+		r.Method = http.MethodGet
+	}
+	for name := range r.Headers {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("empty header name")
+		}
+	}
+	for _, code := range r.ExpectedStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("expected status %d is not an HTTP status", code)
+		}
+	}
+	if r.Body != "" && r.BodyFile != "" {
+		return fmt.Errorf("set either body or body_file, not both")
+	}
+	r.payload = []byte(r.Body)
+	if r.BodyFile != "" {
+		data, err := os.ReadFile(r.BodyFile)
+		if err != nil {
+			return fmt.Errorf("cannot read body file: %w", err)
+		}
+		r.payload = data
+	}
+	return nil
+}
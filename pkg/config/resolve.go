@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Overrides returns the pinned ips for a host:port, the way curl --resolve does
+func (t Target) Overrides(hostport string) []string {
+	return t.Resolve[strings.ToLower(hostport)]
+}
+
+// Pinned returns the ips the target's own host is pinned to, each is probed as a separate backend
+func (t Target) Pinned() []string {
+	port := t.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[t.Scheme()]
+	}
+	return t.Overrides(net.JoinHostPort(t.Hostname(), port))
+}
+
+// validateResolve normalises the host:port keys and ips of the resolve overrides and the resolver address
+func (t *Target) validateResolve(scheme string) error {
+	t.Resolver = normaliseResolver(t.Resolver)
+	if t.Resolver != "" && scheme != "http" && scheme != "https" && scheme != "dns" {
+		return fmt.Errorf("resolver only applies to http, https and dns targets")
+	}
+	if len(t.Resolve) == 0 {
+		return nil
+	}
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("resolve only applies to http and https targets")
+	}
+	resolve := make(map[string][]string, len(t.Resolve))
+	for hostport, ips := range t.Resolve {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(hostport))
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("resolve key %q is not host:port", hostport)
+		}
+		if len(ips) == 0 {
+			return fmt.Errorf("resolve %q has no ips", hostport)
+		}
+		pinned := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			parsed := net.ParseIP(strings.TrimSpace(ip))
+			if parsed == nil {
+				return fmt.Errorf("resolve %q: %q is not an IP", hostport, ip)
+			}
+			pinned = append(pinned, parsed.String())
+		}
+		resolve[strings.ToLower(net.JoinHostPort(host, port))] = pinned
+	}
+	t.Resolve = resolve
+	return nil
+}
+
+// normaliseResolver adds the dns port to a resolver address that has none
+func normaliseResolver(address string) string {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, "53")
+	}
+	return address
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"go.starlark.net/syntax"
+)
+
+// DefaultMaxSteps bounds the starlark execution steps of a script run
+const DefaultMaxSteps = 1_000_000
+
+// ScriptOptions are the starlark dialect options, loops and global reassignment are allowed since
+// runs are bounded by max_steps and the timeout
+var ScriptOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+
+// Script is the starlark program of a script:// target, given inline or as a file
+type Script struct {
+	Source   string `json:"source,omitempty"`
+	File     string `json:"file,omitempty"`
+	MaxSteps uint64 `json:"max_steps,omitempty"`
+	program  string
+}
+
+// Program returns the script source, read from the file when one was given
+func (s *Script) Program() string {
+	return s.program
+}
+
+// Filename is the name errors in the script are reported against
+func (s *Script) Filename() string {
+	if s.File != "" {
+		return s.File
+	}
+	return "script.star"
+}
+
+// validate loads the source and checks that it parses
+func (s *Script) validate() error {
+	if (s.Source == "") == (s.File == "") {
+		return fmt.Errorf("set either script source or file")
+	}
+	s.program = s.Source
+	if s.File != "" {
+		data, err := os.ReadFile(s.File)
+		if err != nil {
+			return fmt.Errorf("cannot read script file: %w", err)
+		}
+		s.program = string(data)
+	}
+	if s.MaxSteps == 0 {
+		s.MaxSteps = DefaultMaxSteps
+	}
+	if _, err := ScriptOptions.Parse(s.Filename(), s.program, 0); err != nil {
+		return fmt.Errorf("script does not parse: %w", err)
+	}
+	return nil
+}
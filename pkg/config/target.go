@@ -0,0 +1,292 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Target is a single monitored endpoint, plain entries in "urls" are loaded as targets with no extra options
+type Target struct {
+	URL string `json:"url"`
+	Request
+	Checks
+	Auth             *Auth               `json:"auth,omitempty"`
+	Redirects        *RedirectPolicy     `json:"redirects,omitempty"`
+	ExpectedFinalURL string              `json:"expected_final_url,omitempty"`
+	TLS              *TLSProfile         `json:"tls,omitempty"`
+	TLSProfile       string              `json:"tls_profile,omitempty"`
+	ContentTracking  *ContentTracking    `json:"content_tracking,omitempty"`
+	Budget           *Budget             `json:"budget,omitempty"`
+	Audit            *Audit              `json:"audit,omitempty"`
+	Send             string              `json:"send,omitempty"`
+	Expect           string              `json:"expect,omitempty"`
+	RecordType       string              `json:"record_type,omitempty"`
+	Resolver         string              `json:"resolver,omitempty"`
+	Expected         []string            `json:"expected,omitempty"`
+	Service          string              `json:"service,omitempty"`
+	StartTLS         bool                `json:"starttls,omitempty"`
+	Query            string              `json:"query,omitempty"`
+	Command          []string            `json:"command,omitempty"`
+	Script           *Script             `json:"script,omitempty"`
+	Protocols        []string            `json:"protocols,omitempty"`
+	AddressFamily    string              `json:"address_family,omitempty"`
+	Resolve          map[string][]string `json:"resolve,omitempty"`
+	Proxy            string              `json:"proxy,omitempty"`
+	Steps            []Step              `json:"steps,omitempty"`
+	expectPattern    *regexp.Regexp
+	parsed           *url.URL
+	address          string
+	proxyURL         *url.URL
+}
+
+// ExpectPattern returns the compiled expect regex, nil when nothing is expected
+func (t Target) ExpectPattern() *regexp.Regexp {
+	return t.expectPattern
+}
+
+// Scheme returns the lower case scheme of the target url
+func (t Target) Scheme() string {
+	return t.parsed.Scheme
+}
+
+// Host returns the host:port of the target url
+func (t Target) Host() string {
+	return t.parsed.Host
+}
+
+// Port returns the port of the target url, empty when it has none
+func (t Target) Port() string {
+	return t.parsed.Port()
+}
+
+// Path returns the path of the target url
+func (t Target) Path() string {
+	return t.parsed.Path
+}
+
+// Hostname returns the host of the target url without the port
+func (t Target) Hostname() string {
+	return t.parsed.Hostname()
+}
+
+// Protocol returns the http version the target is pinned to, empty when it negotiates freely or
+// is probed over several
+func (t Target) Protocol() string {
+	if len(t.Protocols) == 1 {
+		return t.Protocols[0]
+	}
+	return ""
+}
+
+// Address returns the ip the target is pinned to, empty when the host is resolved as usual
+func (t Target) Address() string {
+	return t.address
+}
+
+// WithAddress returns a copy of the target that connects to the given ip instead of resolving its host
+func (t Target) WithAddress(ip string) Target {
+	t.address = ip
+	return t
+}
+
+// NormaliseDNSName lower cases a dns name and drops the trailing root dot
+func NormaliseDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// validate cleans the target url and compiles its assertions
+func (t *Target) validate() error {
+	t.URL = strings.TrimSpace(t.URL)
+	parsed, err := url.Parse(t.URL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid URL: %q", t.URL)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "ws", "wss":
+		if err := t.Request.validate(); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	case "tcp", "grpc", "grpcs":
+		if parsed.Port() == "" {
+			return fmt.Errorf("%s target needs a port: %q", parsed.Scheme, t.URL)
+		}
+	case "smtp", "smtps", "imap", "imaps", "pop3", "pop3s":
+		if t.StartTLS && strings.HasSuffix(parsed.Scheme, "s") {
+			return fmt.Errorf("%s target already uses implicit TLS, starttls does not apply: %q", parsed.Scheme, t.URL)
+		}
+	case "postgres", "postgresql", "mysql", "redis", "rediss":
+		if parsed.User != nil {
+			return fmt.Errorf("put database credentials in auth, not the URL, so they are kept out of results and redacted from logs: %q", parsed.Redacted())
+		}
+		if t.Auth != nil && !strings.EqualFold(strings.TrimSpace(t.Auth.Type), "basic") {
+			return fmt.Errorf("database target %q only supports basic auth", t.URL)
+		}
+	case "exec":
+		if len(t.Command) == 0 || strings.TrimSpace(t.Command[0]) == "" {
+			return fmt.Errorf("exec target %q has no command", t.URL)
+		}
+	case "script":
+		if t.Script == nil {
+			return fmt.Errorf("script target %q has no script", t.URL)
+		}
+		if err := t.Script.validate(); err != nil {
+			return fmt.Errorf("script target %q: %w", t.URL, err)
+		}
+	case "transaction":
+		if len(t.Steps) == 0 {
+			return fmt.Errorf("transaction target %q has no steps", t.URL)
+		}
+		for i := range t.Steps {
+			if err := t.Steps[i].validate(i); err != nil {
+				return fmt.Errorf("transaction target %q: %w", t.URL, err)
+			}
+		}
+	case "dns":
+		if err := t.validateDNS(); err != nil {
+			return fmt.Errorf("dns target %q: %w", t.URL, err)
+		}
+	default:
+		return fmt.Errorf("unsupported URL scheme: %q", t.URL)
+	}
+	t.URL = parsed.String()
+	t.parsed = parsed
+
+	if err := t.validateProtocols(parsed.Scheme); err != nil {
+		return fmt.Errorf("target %q: %w", t.URL, err)
+	}
+	t.AddressFamily = strings.ToLower(strings.TrimSpace(t.AddressFamily))
+	switch t.AddressFamily {
+	case "", "ipv4", "ipv6", "both", "each":
+	default:
+		return fmt.Errorf("target %q: unknown address_family %q, use ipv4, ipv6, both or each", t.URL, t.AddressFamily)
+	}
+	//other probes dial plain tcp, so their family variants would all connect the same way
+	if t.AddressFamily != "" && parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("target %q: address_family only applies to http and https targets", t.URL)
+	}
+	//the redirect policy only applies to plain requests, transaction steps keep go's default and websocket handshakes never follow
+	if t.Redirects != nil || t.ExpectedFinalURL != "" {
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("target %q: redirects and expected_final_url only apply to http and https targets", t.URL)
+		}
+	}
+	if t.Redirects != nil {
+		if err := t.Redirects.validate(parsed.Hostname()); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	}
+	if t.ExpectedFinalURL != "" {
+		final, err := url.Parse(strings.TrimSpace(t.ExpectedFinalURL))
+		if err != nil || final.Scheme == "" || final.Host == "" {
+			return fmt.Errorf("target %q: invalid expected_final_url %q", t.URL, t.ExpectedFinalURL)
+		}
+		t.ExpectedFinalURL = final.String()
+	}
+	if t.Budget != nil {
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("target %q: budget only applies to http and https targets", t.URL)
+		}
+		if err := t.Budget.validate(); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	}
+	if t.ContentTracking != nil {
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("target %q: content_tracking only applies to http and https targets", t.URL)
+		}
+		if err := t.ContentTracking.validate(); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	}
+	if t.Audit != nil {
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("target %q: audit only applies to http and https targets", t.URL)
+		}
+		if err := t.Audit.validate(parsed.Scheme); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	}
+
+	if err := t.validateResolve(parsed.Scheme); err != nil {
+		return fmt.Errorf("target %q: %w", t.URL, err)
+	}
+	if len(t.Pinned()) > 0 && (t.AddressFamily == "both" || t.AddressFamily == "each") {
+		return fmt.Errorf("target %q: address_family %s does not combine with resolve, every pinned ip is already probed", t.URL, t.AddressFamily)
+	}
+
+	if t.Auth != nil {
+		switch parsed.Scheme {
+		case "http", "https", "ws", "wss", "postgres", "postgresql", "mysql", "redis", "rediss":
+		default:
+			return fmt.Errorf("target %q: auth only applies to http, https, ws, wss and database targets", t.URL)
+		}
+		if err := t.Auth.validate(); err != nil {
+			return fmt.Errorf("target %q: %w", t.URL, err)
+		}
+	}
+
+	if t.Expect != "" {
+		t.expectPattern, err = regexp.Compile(t.Expect)
+		if err != nil {
+			return fmt.Errorf("bad expect pattern on %q: %w", t.URL, err)
+		}
+	}
+
+	if err := t.Checks.validate(); err != nil {
+		return fmt.Errorf("target %q: %w", t.URL, err)
+	}
+	return nil
+}
+
+// validateProtocols normalises the pinned http versions, http3 runs over QUIC so it needs https
+func (t *Target) validateProtocols(scheme string) error {
+	for i, protocol := range t.Protocols {
+		protocol = strings.ToLower(strings.TrimSpace(protocol))
+		switch {
+		case protocol != "http1" && protocol != "http2" && protocol != "http3":
+			return fmt.Errorf("unknown protocol %q, use http1, http2 or http3", protocol)
+		case scheme != "http" && scheme != "https":
+			return fmt.Errorf("protocols only apply to http and https targets")
+		case protocol == "http3" && scheme != "https":
+			return fmt.Errorf("http3 needs an https url")
+		}
+		t.Protocols[i] = protocol
+	}
+	slices.Sort(t.Protocols)
+	t.Protocols = slices.Compact(t.Protocols)
+	return nil
+}
+
+// validateDNS checks the record type and resolver and normalises the expected answers so they compare as a sorted set
+func (t *Target) validateDNS() error {
+	t.RecordType = strings.ToUpper(strings.TrimSpace(t.RecordType))
+	if t.RecordType == "" {
+		t.RecordType = "A"
+	}
+	switch t.RecordType {
+	case "A", "AAAA", "CNAME", "MX", "TXT":
+	default:
+		return fmt.Errorf("unsupported record type %q", t.RecordType)
+	}
+	for i, want := range t.Expected {
+		want = strings.TrimSpace(want)
+		switch t.RecordType {
+		case "A", "AAAA":
+			ip := net.ParseIP(want)
+			if ip == nil {
+				return fmt.Errorf("expected value %q is not an IP", want)
+			}
+			want = ip.String()
+		case "CNAME", "MX":
+			want = NormaliseDNSName(want)
+		}
+		t.Expected[i] = want
+	}
+	slices.Sort(t.Expected)
+	t.Expected = slices.Compact(t.Expected)
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTargetValidateScope(t *testing.T) {
+	tests := []struct {
+		target string
+		err    string
+	}{
+		{target: `{"url":"https://example.com/","auth":{"type":"bearer","token":"t"}}`},
+		{target: `{"url":"wss://example.com/feed","auth":{"type":"bearer","token":"t"}}`},
+		{target: `{"url":"postgres://db.example.com/app","auth":{"type":"basic","username":"u","password":"p"}}`},
+		{target: `{"url":"tcp://example.com:22","auth":{"type":"basic","username":"u","password":"p"}}`, err: "auth only applies"},
+		{target: `{"url":"grpc://example.com:50051","auth":{"type":"bearer","token":"t"}}`, err: "auth only applies"},
+		{target: `{"url":"smtp://mail.example.com","auth":{"type":"basic","username":"u","password":"p"}}`, err: "auth only applies"},
+		{target: `{"url":"transaction://login","steps":[{"url":"https://example.com/"}],"auth":{"type":"bearer","token":"t"}}`, err: "auth only applies"},
+		{target: `{"url":"script://check","script":{"source":"x = 1"},"auth":{"type":"bearer","token":"t"}}`, err: "auth only applies"},
+		{target: `{"url":"https://example.com/","redirects":{"policy":"none"},"expected_final_url":"https://example.com/"}`},
+		{target: `{"url":"transaction://login","steps":[{"url":"https://example.com/"}],"redirects":{"policy":"none"}}`, err: "redirects and expected_final_url only apply"},
+		{target: `{"url":"transaction://login","steps":[{"url":"https://example.com/"}],"expected_final_url":"https://example.com/home"}`, err: "redirects and expected_final_url only apply"},
+		{target: `{"url":"wss://example.com/feed","redirects":{"policy":"follow"}}`, err: "redirects and expected_final_url only apply"},
+		{target: `{"url":"https://example.com/","protocols":["HTTP2","http3"]}`},
+		{target: `{"url":"tcp://example.com:22","protocols":["http1","http2"]}`, err: "protocols only apply"},
+		{target: `{"url":"wss://example.com/feed","protocols":["http1"]}`, err: "protocols only apply"},
+		{target: `{"url":"transaction://login","steps":[{"url":"https://example.com/"}],"protocols":["http2"]}`, err: "protocols only apply"},
+		{target: `{"url":"http://example.com/","address_family":"Both"}`},
+		{target: `{"url":"tcp://127.0.0.1:22","address_family":"both"}`, err: "address_family only applies"},
+		{target: `{"url":"dns://example.com","address_family":"ipv6"}`, err: "address_family only applies"},
+		{target: `{"url":"wss://example.com/feed","address_family":"each"}`, err: "address_family only applies"},
+		{target: `{"url":"tcp://127.0.0.1:22","address_family":"ipv5"}`, err: "unknown address_family"},
+		{target: `{"url":"https://example.com/","budget":{"max_latency_ms":800}}`},
+		{target: `{"url":"tcp://example.com:443","budget":{"max_latency_ms":800}}`, err: "budget only applies"},
+		{target: `{"url":"dns://example.com","budget":{"max_latency_ms":800}}`, err: "budget only applies"},
+		{target: `{"url":"transaction://login","steps":[{"url":"https://example.com/"}],"budget":{"max_latency_ms":800}}`, err: "budget only applies"},
+		{target: `{"url":"wss://example.com/feed","budget":{"max_latency_ms":800}}`, err: "budget only applies"},
+	}
+	for _, tt := range tests {
+		var target Target
+		if err := json.Unmarshal([]byte(tt.target), &target); err != nil {
+			t.Fatal(err)
+		}
+		err := target.validate()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.target, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: got error %v, want %q", tt.target, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSProfile is the client side tls setup of a target, defined inline or by name in tls_profiles
+type TLSProfile struct {
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	CAFile     string `json:"ca_file,omitempty"`
+	MinVersion string `json:"min_version,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	tlsConfig  *tls.Config
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig returns a copy of the tls config built from the profile
+func (p *TLSProfile) TLSConfig() *tls.Config {
+	return p.tlsConfig.Clone()
+}
+
+// Key identifies the profile, targets with equal keys share a transport
+func (p *TLSProfile) Key() string {
+	return strings.Join([]string{p.ClientCert, p.ClientKey, p.CAFile, p.MinVersion, p.ServerName}, "|")
+}
+
+// load reads the certificate files and builds the tls config, loading twice is a no-op
+func (p *TLSProfile) load() error {
+	if p.tlsConfig != nil {
+		return nil
+	}
+	cfg := &tls.Config{ServerName: strings.TrimSpace(p.ServerName)}
+	if p.MinVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(p.MinVersion)]
+		if !ok {
+			return fmt.Errorf("unknown min_version %q, use 1.0 to 1.3", p.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	if (p.ClientCert == "") != (p.ClientKey == "") {
+		return fmt.Errorf("client_cert and client_key must be set together")
+	}
+	if p.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(p.ClientCert, p.ClientKey)
+		if err != nil {
+			return fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in ca_file %q", p.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	p.tlsConfig = cfg
+	return nil
+}
+
+// resolveTLSProfile points the target at its named profile and loads whichever profile it ends up with
+func resolveTLSProfile(t *Target, profiles map[string]*TLSProfile) error {
+	if t.TLSProfile != "" {
+		if t.TLS != nil {
+			return fmt.Errorf("target %q sets both tls and tls_profile", t.URL)
+		}
+		profile, ok := profiles[t.TLSProfile]
+		if !ok {
+			return fmt.Errorf("target %q uses unknown tls_profile %q", t.URL, t.TLSProfile)
+		}
+		t.TLS = profile
+	}
+	if t.TLS == nil {
+		return nil
+	}
+	if err := t.TLS.load(); err != nil {
+		return fmt.Errorf("target %q: %w", t.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/jsonpath"
+)
+
+// Step is one request of a transaction target. {{name}} in the url, header values and body is
+// replaced with variables extracted by earlier steps
+type Step struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Request
+	Checks
+	Extract     map[string]string `json:"extract,omitempty"`
+	jsonExtract map[string]*jsonpath.Expr
+}
+
+// ExtractPath returns the parsed path of a json: extraction
+func (s Step) ExtractPath(variable string) *jsonpath.Expr {
+	return s.jsonExtract[variable]
+}
+
+func (s *Step) validate(index int) error {
+	if strings.TrimSpace(s.Name) == "" {
+		s.Name = fmt.Sprintf("step %d", index+1)
+	}
+	s.URL = strings.TrimSpace(s.URL)
+	//urls built from variables can only be checked once they are filled in
+	if !strings.Contains(s.URL, "{{") {
+		parsed, err := url.Parse(s.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s: invalid URL %q", s.Name, s.URL)
+		}
+	}
+	if err := s.Request.validate(); err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if err := s.Checks.validate(); err != nil {
+		return fmt.Errorf("%s: %w", s.Name, err)
+	}
+	s.jsonExtract = make(map[string]*jsonpath.Expr)
+	for variable, source := range s.Extract {
+		kind, from, _ := strings.Cut(source, ":")
+		switch kind {
+		case "header", "cookie":
+			if strings.TrimSpace(from) == "" {
+				return fmt.Errorf("%s: extract %q needs a %s name", s.Name, variable, kind)
+			}
+		case "json":
+			expr, err := jsonpath.Parse(from)
+			if err != nil {
+				return fmt.Errorf("%s: extract %q: %w", s.Name, variable, err)
+			}
+			s.jsonExtract[variable] = expr
+		default:
+			return fmt.Errorf("%s: extract %q must start with header:, cookie: or json:", s.Name, variable)
+		}
+	}
+	return nil
+}
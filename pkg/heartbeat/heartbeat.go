@@ -0,0 +1,167 @@
+package heartbeat
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+// longest failure message kept from a /fail check-in body
+const maxMessageBytes = 1024
+
+// monitor is the live state of one heartbeat, deadline is when the next check-in is due
+type monitor struct {
+	mu        sync.Mutex
+	cfg       config.Heartbeat
+	deadline  time.Time
+	startedAt time.Time
+}
+
+// Listen serves the check-in endpoints and reports check-ins and missed deadlines as ping results,
+// so heartbeats go through the same aggregator and analyser as pulled targets. A listener that cannot
+// serve shuts the monitor down, since every heartbeat would otherwise miss its check-ins for good
+func Listen(address string, heartbeats []config.Heartbeat, results chan pinger.PingResult, finish context.Context, cancel context.CancelFunc, wg *sync.WaitGroup) {
+	defer wg.Done()
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logger.Log.Error("Heartbeat listener error", zap.Error(err))
+		cancel()
+		return
+	}
+	monitors := make(map[string]*monitor, len(heartbeats))
+	now := time.Now()
+	for _, h := range heartbeats {
+		monitors[h.ID] = &monitor{cfg: h, deadline: now.Add(h.Period() + h.Grace())}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/heartbeat/{id}", checkInHandler(monitors, "", results, finish))
+	mux.HandleFunc("/heartbeat/{id}/start", checkInHandler(monitors, "start", results, finish))
+	mux.HandleFunc("/heartbeat/{id}/fail", checkInHandler(monitors, "fail", results, finish))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Error("Heartbeat listener error", zap.Error(err))
+			cancel()
+		}
+	}()
+	logger.Log.Info("Heartbeat check-ins served on " + listener.Addr().String())
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-finish.Done():
+			fmt.Println("Deactivating Heartbeat Listener")
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			server.Shutdown(shutdown)
+			cancel()
+			return
+		case now := <-ticker.C:
+			for _, m := range monitors {
+				if res, missed := m.overdue(now); missed {
+					report(res, results, finish)
+				}
+			}
+		}
+	}
+}
+
+// overdue returns a failed result once the deadline passes, then moves the deadline a period on
+// so a job that stays silent keeps failing once per period
+func (m *monitor) overdue(now time.Time) (pinger.PingResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !now.After(m.deadline) {
+		return pinger.PingResult{}, false
+	}
+	res := pinger.PingResult{URL: m.cfg.URL(), Status: -1, TimestampUTC: now.UTC(), WorkerID: -1}
+	if !m.startedAt.IsZero() {
+		res.Error = fmt.Sprintf("run started at %s did not finish within grace", m.startedAt.UTC().Format(time.RFC3339))
+		res.ResponseMS = now.Sub(m.startedAt).Milliseconds()
+		m.startedAt = time.Time{}
+	} else {
+		res.Error = fmt.Sprintf("missed check-in due by %s", m.deadline.UTC().Format(time.RFC3339))
+	}
+	m.deadline = now.Add(m.cfg.Period())
+	return res, true
+}
+
+// checkIn records a check-in of the given kind, returning a result unless it was a start
+func (m *monitor) checkIn(kind, message string, now time.Time) (pinger.PingResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if kind == "start" {
+		m.startedAt = now
+		if m.cfg.GraceSecs > 0 {
+			m.deadline = now.Add(m.cfg.Grace())
+		}
+		return pinger.PingResult{}, false
+	}
+	res := pinger.PingResult{URL: m.cfg.URL(), TimestampUTC: now.UTC(), WorkerID: -1}
+	if !m.startedAt.IsZero() { //run duration is only known when the job sent a start
+		res.ResponseMS = now.Sub(m.startedAt).Milliseconds()
+	}
+	if kind == "fail" {
+		res.Status = -1
+		res.Error = "job reported failure"
+		if message != "" {
+			res.Error += ": " + message
+		}
+	}
+	m.startedAt = time.Time{}
+	m.deadline = now.Add(m.cfg.Period() + m.cfg.Grace())
+	return res, true
+}
+
+// authorized checks the token of a check-in, sent as a bearer token or, for clients that can only
+// call a url, as the token query parameter
+func (m *monitor) authorized(r *http.Request) bool {
+	if m.cfg.Token == "" {
+		return true
+	}
+	presented := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(m.cfg.Token)) == 1
+}
+
+func checkInHandler(monitors map[string]*monitor, kind string, results chan pinger.PingResult, finish context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m, ok := monitors[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if !m.authorized(r) {
+			http.Error(w, "missing or wrong heartbeat token", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+		if res, ok := m.checkIn(kind, strings.TrimSpace(string(body)), time.Now()); ok {
+			report(res, results, finish)
+		}
+		fmt.Fprintln(w, "OK")
+	}
+}
+
+func report(res pinger.PingResult, results chan pinger.PingResult, finish context.Context) {
+	select {
+	case <-finish.Done():
+	case results <- res:
+		//enqueue alongside worker results
+	}
+}
@@ -0,0 +1,86 @@
+package heartbeat
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/logger"
+	"github.com/sairamkumarm/gositemonitor/pkg/pinger"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger.Log = zap.NewNop()
+}
+
+func TestListenAddressInUse(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	finish, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan pinger.PingResult, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go Listen(taken.Addr().String(), []config.Heartbeat{{ID: "nightly-backup", PeriodSecs: 1}}, results, finish, cancel, &wg)
+	select {
+	case <-finish.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("a listener that could not bind kept the monitor running")
+	}
+	wg.Wait()
+	select {
+	case res := <-results:
+		t.Errorf("got a result %+v from a listener that never served", res)
+	default:
+	}
+}
+
+func TestCheckInToken(t *testing.T) {
+	monitors := map[string]*monitor{
+		"nightly-backup": {cfg: config.Heartbeat{ID: "nightly-backup", PeriodSecs: 60, Token: "s3cret"}},
+		"open":           {cfg: config.Heartbeat{ID: "open", PeriodSecs: 60}},
+	}
+	results := make(chan pinger.PingResult, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/heartbeat/{id}", checkInHandler(monitors, "", results, context.Background()))
+	tests := []struct {
+		path   string
+		bearer string
+		status int
+	}{
+		{path: "/heartbeat/nightly-backup", status: http.StatusUnauthorized},
+		{path: "/heartbeat/nightly-backup?token=wrong", status: http.StatusUnauthorized},
+		{path: "/heartbeat/nightly-backup", bearer: "wrong", status: http.StatusUnauthorized},
+		{path: "/heartbeat/nightly-backup?token=s3cret", status: http.StatusOK},
+		{path: "/heartbeat/nightly-backup", bearer: "s3cret", status: http.StatusOK},
+		{path: "/heartbeat/open", status: http.StatusOK},
+		{path: "/heartbeat/missing", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		if tt.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.bearer)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s bearer %q: got %d, want %d", tt.path, tt.bearer, rec.Code, tt.status)
+		}
+		reported := len(results) > 0
+		if reported {
+			<-results
+		}
+		if reported != (tt.status == http.StatusOK) {
+			t.Errorf("%s bearer %q: reported a check-in %v", tt.path, tt.bearer, reported)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed assertion of the form `$.path.to[0].field OP literal`,
+// a bare path only checks that the field exists
+type Expr struct {
+	source string
+	path   []segment
+	op     string
+	want   any
+}
+
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func Parse(source string) (*Expr, error) {
+	s := strings.TrimSpace(source)
+	if s == "" {
+		return nil, fmt.Errorf("empty json assertion")
+	}
+	end := pathEnd(s)
+	path, err := parsePath(s[:end])
+	if err != nil {
+		return nil, fmt.Errorf("json assertion %q: %w", source, err)
+	}
+	e := &Expr{source: s, path: path, op: "exists"}
+	rest := strings.TrimSpace(s[end:])
+	if rest == "" {
+		return e, nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			e.op = op
+			break
+		}
+	}
+	if e.op == "exists" {
+		return nil, fmt.Errorf("json assertion %q: unknown operator", source)
+	}
+	literal := strings.TrimSpace(rest[len(e.op):])
+	if err := json.Unmarshal([]byte(literal), &e.want); err != nil {
+		return nil, fmt.Errorf("json assertion %q: bad value %q", source, literal)
+	}
+	switch e.want.(type) {
+	case float64, string:
+	default:
+		if e.op != "==" && e.op != "!=" {
+			return nil, fmt.Errorf("json assertion %q: %s needs a number or string", source, e.op)
+		}
+	}
+	return e, nil
+}
+
+// parsePath reads paths like $.a.b[0]["c.d"], the leading $ is optional
+func parsePath(p string) ([]segment, error) {
+	p = strings.TrimPrefix(p, "$")
+	var segs []segment
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path")
+			}
+			segs = append(segs, segment{key: p[:end]})
+			p = p[end:]
+		case '[':
+			end := bracketEnd(p)
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in path")
+			}
+			inner := p[1:end]
+			p = p[end+1:]
+			if key, err := strconv.Unquote(inner); err == nil {
+				segs = append(segs, segment{key: key})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("bad index [%s] in path", inner)
+			}
+			segs = append(segs, segment{index: idx, isIndex: true})
+		default:
+			//gjson style paths without the leading dot
+			if len(segs) == 0 {
+				p = "." + p
+				continue
+			}
+			return nil, fmt.Errorf("unexpected %q in path", p[0])
+		}
+	}
+	return segs, nil
+}
+
+// pathEnd finds where the path ends and the operator starts, stepping over quoted keys
+func pathEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			end := quoteEnd(s, i)
+			if end == -1 {
+				return len(s)
+			}
+			i = end
+		case ' ', '\t', '=', '!', '<', '>':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// bracketEnd returns the index of the ] closing the bracket p starts with, -1 when there is none
+func bracketEnd(p string) int {
+	for i := 1; i < len(p); i++ {
+		switch p[i] {
+		case '"':
+			if i = quoteEnd(p, i); i == -1 {
+				return -1
+			}
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// quoteEnd returns the index of the quote closing the one at start, -1 when it is unterminated
+func quoteEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Evaluate runs the expression against a decoded json document, returning the value
+// found at the path (json encoded) and whether the assertion held
+func (e *Expr) Evaluate(doc any) (string, bool) {
+	cur, ok := e.Lookup(doc)
+	if !ok {
+		return "<missing>", false
+	}
+	actual, _ := json.Marshal(cur)
+	return string(actual), e.compare(cur)
+}
+
+// Lookup returns the decoded value at the expression's path
+func (e *Expr) Lookup(doc any) (any, bool) {
+	cur := doc
+	for _, seg := range e.path {
+		if seg.isIndex {
+			arr, ok := cur.([]any)
+			if !ok || seg.index >= len(arr) {
+				return nil, false
+			}
+			cur = arr[seg.index]
+		} else {
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if cur, ok = obj[seg.key]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return cur, true
+}
+
+func (e *Expr) compare(got any) bool {
+	switch e.op {
+	case "exists":
+		return true
+	case "==":
+		return equal(got, e.want)
+	case "!=":
+		return !equal(got, e.want)
+	}
+	var cmp int
+	switch want := e.want.(type) {
+	case float64:
+		g, ok := got.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareOrdered(g, want)
+	case string:
+		g, ok := got.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(g, want)
+	}
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// equal compares decoded json values, objects and arrays by their encoding
+func equal(a, b any) bool {
+	switch a.(type) {
+	case map[string]any, []any:
+		ea, _ := json.Marshal(a)
+		eb, _ := json.Marshal(b)
+		return string(ea) == string(eb)
+	}
+	return a == b
+}
@@ -0,0 +1,89 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const doc = `{
+	"status": "ok",
+	"uptime": 42.5,
+	"ready": true,
+	"error": null,
+	"a b": 1,
+	"x=y": "eq",
+	"q\"k": "quoted",
+	"br]ck": 2,
+	"db": {"replicas": [{"lag": 3}, {"lag": 12}], "name.with.dots": "main"},
+	"tags": ["a", "b"]
+}`
+
+func TestEvaluate(t *testing.T) {
+	var decoded any
+	if err := json.Unmarshal([]byte(doc), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr   string
+		actual string
+		ok     bool
+	}{
+		{`$.status == "ok"`, `"ok"`, true},
+		{`$.status != "ok"`, `"ok"`, false},
+		{`$.uptime > 40`, `42.5`, true},
+		{`$.uptime >= 42.5`, `42.5`, true},
+		{`$.uptime < 42.5`, `42.5`, false},
+		{`$.uptime <= 42.5`, `42.5`, true},
+		{`$.status < "pk"`, `"ok"`, true},
+		{`$.ready == true`, `true`, true},
+		{`$.error == null`, `null`, true},
+		{`$.tags == ["a","b"]`, `["a","b"]`, true},
+		{`$.db.replicas[1].lag > 10`, `12`, true},
+		{`$.db.replicas[0]["lag"]==3`, `3`, true},
+		{`$.db["name.with.dots"] == "main"`, `"main"`, true},
+		{`$["a b"] == 1`, `1`, true},
+		{`$["x=y"] == "eq"`, `"eq"`, true},
+		{`$["q\"k"] == "quoted"`, `"quoted"`, true},
+		{`$["br]ck"] == 2`, `2`, true},
+		{`$.tags[1]`, `"b"`, true},
+		{`status`, `"ok"`, true},
+		{`db.replicas[0].lag == 3`, `3`, true},
+		{`$.missing`, `<missing>`, false},
+		{`$.tags[5]`, `<missing>`, false},
+		{`$.status[0]`, `<missing>`, false},
+		{`$.uptime > "a"`, `42.5`, false},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		actual, ok := e.Evaluate(decoded)
+		if actual != tt.actual || ok != tt.ok {
+			t.Errorf("%q = %s, %v, want %s, %v", tt.expr, actual, ok, tt.actual, tt.ok)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`$.`,
+		`$.a..b`,
+		`$.a[`,
+		`$.a[x]`,
+		`$.a[-1]`,
+		`$["unterminated] == 1`,
+		`$.a ~= 1`,
+		`$.a == `,
+		`$.a == nope`,
+		`$.a > true`,
+		`$.a >= null`,
+		`$.a[0]b`,
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -34,20 +34,65 @@ func ResultLogger(res pinger.PingResult) {
 	switch {
 	case res.Status == -1:
 		Log.Error("Ping Failed",
-			zap.String("URL", res.URL),
+			zap.String("URL", res.Key()),
 			zap.Time("TimeStampUTC", res.TimestampUTC),
 			zap.String("Error", res.Error),
 			zap.Int("WorkerID", res.WorkerID))
-	case res.Status >= 400:
+	case res.AuthError != "":
+		Log.Error("Auth Failed",
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.String("Error", res.AuthError),
+			zap.Int("WorkerID", res.WorkerID))
+	case res.ProxyError != "":
+		Log.Error("Proxy Failed",
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.String("Proxy", res.Proxy),
+			zap.String("Error", res.ProxyError),
+			zap.Int("WorkerID", res.WorkerID))
+	case res.FailedAssertion != "":
+		Log.Warn("Assertion Failed",
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.Int("Status", res.Status),
+			zap.String("Assertion", res.FailedAssertion),
+			zap.String("Actual", res.ActualValue),
+			zap.Int("Latency", int(res.ResponseMS)),
+			zap.Int("WorkerID", res.WorkerID))
+	case len(res.BudgetViolations) > 0:
+		Log.Warn("Over Budget",
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.Int("Status", res.Status),
+			zap.Strings("Violations", res.BudgetViolations),
+			zap.Int("Latency", int(res.ResponseMS)),
+			zap.Int("WorkerID", res.WorkerID))
+	case len(res.AuditFailures) > 0:
+		Log.Warn("Audit Failed",
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.Int("Status", res.Status),
+			zap.Any("AuditFailures", res.AuditFailures),
+			zap.Int("Latency", int(res.ResponseMS)),
+			zap.Int("WorkerID", res.WorkerID))
+	case res.CheckState != "" && res.CheckState != "OK":
+		Log.Warn("Check "+res.CheckState,
+			zap.String("URL", res.Key()),
+			zap.Time("TimeStampUTC", res.TimestampUTC),
+			zap.String("StatusLine", res.StatusLine),
+			zap.Int("Latency", int(res.ResponseMS)),
+			zap.Int("WorkerID", res.WorkerID))
+	case res.Failed():
 		Log.Warn("Non-2XX Status",
-			zap.String("URL", res.URL),
+			zap.String("URL", res.Key()),
 			zap.Time("TimeStampUTC", res.TimestampUTC),
 			zap.Int("Status", res.Status),
 			zap.Int("Latency", int(res.ResponseMS)),
 			zap.Int("WorkerID", res.WorkerID))
 	default:
 		Log.Info("Ping Success",
-			zap.String("URL", res.URL),
+			zap.String("URL", res.Key()),
 			zap.Time("TimeStampUTC", res.TimestampUTC),
 			zap.Int("Status", res.Status),
 			zap.Int("Latency", int(res.ResponseMS)),
@@ -0,0 +1,56 @@
+package pinger
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"github.com/sairamkumarm/gositemonitor/pkg/jsonpath"
+)
+
+// longest actual value kept on a result, json bodies can be large
+const maxActualLen = 256
+
+// checkBody runs the body assertions in order and returns the first one that failed, empty if all passed
+func checkBody(body []byte, assertions []config.Assertion) string {
+	for _, a := range assertions {
+		var passed bool
+		switch a.Type {
+		case "contains":
+			passed = bytes.Contains(body, []byte(a.Value))
+		case "not_contains":
+			passed = !bytes.Contains(body, []byte(a.Value))
+		case "regex":
+			passed = a.Pattern().Match(body)
+		case "not_regex":
+			passed = !a.Pattern().Match(body)
+		}
+		if !passed {
+			return a.String()
+		}
+	}
+	return ""
+}
+
+// checkJSON decodes the body and runs the json assertions, returning the first failed
+// expression with the value that was actually found
+func checkJSON(body []byte, exprs []*jsonpath.Expr) (string, string) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "valid json body", truncate(err.Error())
+	}
+	for _, expr := range exprs {
+		actual, ok := expr.Evaluate(doc)
+		if !ok {
+			return expr.String(), truncate(actual)
+		}
+	}
+	return "", ""
+}
+
+func truncate(s string) string {
+	if len(s) > maxActualLen {
+		return s[:maxActualLen] + "..."
+	}
+	return s
+}
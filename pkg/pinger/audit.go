@@ -0,0 +1,154 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// AuditFailure is a security audit rule the target broke, Rule stays the same between pings so
+// regressions can be tracked, Actual says what was seen
+type AuditFailure struct {
+	Rule   string `json:"rule"`
+	Actual string `json:"actual,omitempty"`
+}
+
+// protocol versions below TLS 1.3 the audit tries to handshake with, lowest first
+var auditVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12}
+
+// allCiphers is every suite go can offer, so a version probe is not refused over the suites alone
+var allCiphers = func() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}()
+
+// weakCiphers are the suites go marks insecure plus the rsa key exchange ones, which lack forward secrecy
+var weakCiphers = func() []uint16 {
+	var ids []uint16
+	for _, suite := range tls.InsecureCipherSuites() {
+		ids = append(ids, suite.ID)
+	}
+	for _, suite := range tls.CipherSuites() {
+		if strings.HasPrefix(suite.Name, "TLS_RSA_") {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}()
+
+// audit checks a response against the target's security policy. The tls rules look at the negotiated
+// connection and also try handshakes the server should refuse, since a ping only sees the best it offers
+func audit(ctx context.Context, res *PingResult, resp *http.Response, target config.Target) {
+	policy := target.Audit
+	res.audited = true
+	fail := func(rule, actual string) {
+		res.AuditFailures = append(res.AuditFailures, AuditFailure{Rule: rule, Actual: truncate(actual)})
+	}
+	names := make([]string, 0, len(policy.Required()))
+	for name := range policy.Required() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		values := resp.Header.Values(name)
+		pattern := policy.Required()[name]
+		switch {
+		case len(values) == 0:
+			fail("header "+name, "missing")
+		case pattern != nil && !pattern.MatchString(strings.Join(values, ", ")):
+			fail("header "+name+" ~ "+pattern.String(), strings.Join(values, ", "))
+		}
+	}
+	for _, name := range policy.ForbidHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			fail("no header "+name, strings.Join(values, ", "))
+		}
+	}
+	if minVersion := policy.MinVersion(); minVersion != 0 {
+		rule := "min tls " + tls.VersionName(minVersion)
+		if resp.TLS != nil && resp.TLS.Version < minVersion {
+			fail(rule, tls.VersionName(resp.TLS.Version))
+		} else {
+			for _, version := range auditVersions {
+				if version < minVersion && cachedHandshake(ctx, target, version, version, allCiphers) != nil {
+					fail(rule, "accepts "+tls.VersionName(version))
+					break
+				}
+			}
+		}
+	}
+	if policy.ForbidWeakCiphers {
+		if resp.TLS != nil && slices.Contains(weakCiphers, resp.TLS.CipherSuite) {
+			fail("no weak ciphers", tls.CipherSuiteName(resp.TLS.CipherSuite))
+		} else if state := cachedHandshake(ctx, target, tls.VersionTLS10, tls.VersionTLS12, weakCiphers); state != nil {
+			fail("no weak ciphers", "accepts "+tls.CipherSuiteName(state.CipherSuite))
+		}
+	}
+}
+
+// handshakes holds the outcome of recent probe handshakes, so the variants and repeated pings of a target
+// within one request interval share a probe instead of each opening several connections of its own
+var handshakes sync.Map
+
+type handshakeOutcome struct {
+	state *tls.ConnectionState
+	at    time.Time
+}
+
+// cachedHandshake is acceptsHandshake run at most once per request interval for each address and probe
+func cachedHandshake(ctx context.Context, target config.Target, minVersion, maxVersion uint16, suites []uint16) *tls.ConnectionState {
+	key := fmt.Sprint(target.Host(), "|", target.Address(), "|", target.Resolve, "|", minVersion, "-", maxVersion, "|", suites)
+	if target.TLS != nil {
+		key += "|" + target.TLS.Key()
+	}
+	interval := config.ProdConfig.GetRequestIntervalDuration()
+	if cached, ok := handshakes.Load(key); ok && time.Since(cached.(handshakeOutcome).at) < interval {
+		return cached.(handshakeOutcome).state
+	}
+	state := acceptsHandshake(ctx, target, minVersion, maxVersion, suites)
+	handshakes.Store(key, handshakeOutcome{state: state, at: time.Now()})
+	//drop outcomes of addresses that are no longer probed
+	handshakes.Range(func(key, cached any) bool {
+		if time.Since(cached.(handshakeOutcome).at) >= interval {
+			handshakes.Delete(key)
+		}
+		return true
+	})
+	return state
+}
+
+// acceptsHandshake offers the target only the given versions and suites, returning the connection
+// state when the server takes it up and nil when it refuses or cannot be reached
+func acceptsHandshake(ctx context.Context, target config.Target, minVersion, maxVersion uint16, suites []uint16) *tls.ConnectionState {
+	addr := target.Host()
+	if target.Port() == "" {
+		addr = net.JoinHostPort(target.Hostname(), "443")
+	}
+	conn, err := pinnedDial(target)(ctx, "tcp", addr)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	cfg := targetTLSConfig(target)
+	//only whether the server agrees matters here, the certificate is checked by the ping itself
+	cfg.InsecureSkipVerify = true
+	cfg.MinVersion, cfg.MaxVersion = minVersion, maxVersion
+	cfg.CipherSuites = suites
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	return &state
+}
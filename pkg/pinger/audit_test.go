@@ -0,0 +1,66 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAuditProbes(t *testing.T) {
+	cert, caFile := selfSigned(t, "audit.test")
+	var conns atomic.Int32
+	status := http.StatusOK
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	//refused probes are expected, keep them out of the test output
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	targets := loadTargets(t, fmt.Sprintf(`{"request_interval":60,"targets":[{"url":"https://audit.test:%s/",
+		"resolve":{"audit.test:%s":["127.0.0.1"]},"tls":{"ca_file":%q},
+		"audit":{"min_tls_version":"1.2","forbid_weak_ciphers":true}}]}`, port, port, caFile))
+	client := &http.Client{Transport: &http.Transport{}, Timeout: 2 * time.Second}
+
+	first := timedGet(targets[0], 2*time.Second, client)
+	if !first.Audited() || len(first.AuditFailures) != 0 {
+		t.Fatalf("first ping: audited %v failures %+v, want a clean audit", first.Audited(), first.AuditFailures)
+	}
+	probed := conns.Load()
+	if probed < 2 {
+		t.Fatalf("first ping opened %d connections, want the ping and its probes", probed)
+	}
+	second := timedGet(targets[0], 2*time.Second, client)
+	if !second.Audited() {
+		t.Error("second ping was not audited")
+	}
+	if extra := conns.Load() - probed; extra > 1 {
+		t.Errorf("second ping within the interval opened %d connections, want the probes reused", extra)
+	}
+
+	handshakes.Clear()
+	status = http.StatusInternalServerError
+	before := conns.Load()
+	failed := timedGet(targets[0], 2*time.Second, client)
+	if failed.Audited() || len(failed.AuditFailures) != 0 {
+		t.Errorf("failed ping: audited %v failures %+v, want no audit", failed.Audited(), failed.AuditFailures)
+	}
+	if extra := conns.Load() - before; extra > 1 {
+		t.Errorf("failed ping opened %d connections, want no probes", extra)
+	}
+}
@@ -0,0 +1,122 @@
+package pinger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// tokens without an expires_in are refetched after this long
+const defaultTokenLifetime = 5 * time.Minute
+
+// longest a token is refreshed ahead of its expiry
+const maxRefreshMargin = time.Minute
+
+type cachedToken struct {
+	mu        sync.Mutex
+	value     string
+	refreshAt time.Time
+}
+
+// tokens caches oauth2 tokens per token url, client and scope set
+var tokens sync.Map
+
+// authorize applies the target's auth to the request, fetching an oauth2 token when needed. client is the
+// target's own, so its tls profile and proxy also apply to the token endpoint
+func authorize(ctx context.Context, req *http.Request, auth *config.Auth, client *http.Client) error {
+	if auth == nil {
+		return nil
+	}
+	switch auth.Type {
+	case "basic":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case "oauth2":
+		token, err := oauthToken(ctx, auth, client)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// forgetToken drops a cached oauth2 token, used when the target rejects it
+func forgetToken(auth *config.Auth) {
+	if auth == nil || auth.Type != "oauth2" {
+		return
+	}
+	if entry, ok := tokens.Load(tokenKey(auth)); ok {
+		cached := entry.(*cachedToken)
+		cached.mu.Lock()
+		cached.refreshAt = time.Time{}
+		cached.mu.Unlock()
+	}
+}
+
+func tokenKey(auth *config.Auth) string {
+	return auth.TokenURL + "|" + auth.ClientID + "|" + strings.Join(auth.Scopes, " ")
+}
+
+// oauthToken returns a cached token, refreshing it shortly before it expires. The entry
+// is locked while fetching so targets sharing a client don't all hit the token endpoint
+func oauthToken(ctx context.Context, auth *config.Auth, client *http.Client) (string, error) {
+	entry, _ := tokens.LoadOrStore(tokenKey(auth), &cachedToken{})
+	cached := entry.(*cachedToken)
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	if cached.value != "" && time.Now().Before(cached.refreshAt) {
+		return cached.value, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, truncate(string(body)))
+	}
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+
+	lifetime := defaultTokenLifetime
+	if payload.ExpiresIn > 0 {
+		lifetime = time.Duration(payload.ExpiresIn) * time.Second
+	}
+	cached.value = payload.AccessToken
+	cached.refreshAt = time.Now().Add(lifetime - min(lifetime/10, maxRefreshMargin))
+	return cached.value, nil
+}
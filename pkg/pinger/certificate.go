@@ -0,0 +1,67 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"time"
+)
+
+type CertInfo struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+	SANs     []string  `json:"sans,omitempty"`
+}
+
+// TLSInfo is the peer certificate chain of an https target, Expiry is the earliest expiry in the chain
+type TLSInfo struct {
+	Expiry      time.Time  `json:"expiry"`
+	Chain       []CertInfo `json:"chain"`
+	VerifyError string     `json:"verify_error,omitempty"`
+}
+
+func newTLSInfo(certs []*x509.Certificate) *TLSInfo {
+	if len(certs) == 0 {
+		return nil
+	}
+	info := &TLSInfo{Chain: make([]CertInfo, 0, len(certs))}
+	for _, cert := range certs {
+		sans := append([]string{}, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		info.Chain = append(info.Chain, CertInfo{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter.UTC(),
+			SANs:     sans,
+		})
+		if info.Expiry.IsZero() || cert.NotAfter.Before(info.Expiry) {
+			info.Expiry = cert.NotAfter.UTC()
+		}
+	}
+	return info
+}
+
+// tlsInfoFromState reads the chain of a completed handshake
+func tlsInfoFromState(state *tls.ConnectionState) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	return newTLSInfo(state.PeerCertificates)
+}
+
+// tlsInfoFromError recovers the chain from a failed verification, covering untrusted
+// chains, expired certificates and hostname mismatches
+func tlsInfoFromError(err error) *TLSInfo {
+	var verr *tls.CertificateVerificationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	info := newTLSInfo(verr.UnverifiedCertificates)
+	if info != nil {
+		info.VerifyError = verr.Err.Error()
+	}
+	return info
+}
@@ -0,0 +1,37 @@
+package pinger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+var whitespace = regexp.MustCompile(`[ \t\r\f\v]+`)
+
+// normaliseContent strips the ignored parts of a body and, when asked, collapses whitespace and blank lines
+func normaliseContent(body []byte, tracking *config.ContentTracking) []byte {
+	content := body
+	for _, re := range tracking.Ignore() {
+		content = re.ReplaceAll(content, nil)
+	}
+	if !tracking.Normalize {
+		return content
+	}
+	lines := bytes.Split(content, []byte("\n"))
+	kept := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		line = bytes.TrimSpace(whitespace.ReplaceAll(line, []byte(" ")))
+		if len(line) > 0 {
+			kept = append(kept, line)
+		}
+	}
+	return bytes.Join(kept, []byte("\n"))
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
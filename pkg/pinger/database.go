@@ -0,0 +1,248 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+var defaultQueries = map[string]string{
+	"postgres": "SELECT 1",
+	"mysql":    "SELECT 1",
+	"redis":    "PING",
+}
+
+// timedQuery opens a fresh connection to a database target and runs its query, recording connect
+// and query time separately. With expect set the first value returned must match it
+func timedQuery(target config.Target, timeout time.Duration) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	query := target.Query
+	kind := databaseKind(target.Scheme())
+	if query == "" {
+		query = defaultQueries[kind]
+	}
+	var value string
+	var err error
+	switch kind {
+	case "postgres":
+		value, err = queryPostgres(ctx, target, query, &res)
+	case "mysql":
+		value, err = queryMySQL(ctx, target, query, &res)
+	case "redis":
+		value, err = queryRedis(ctx, target, query, &res)
+	}
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	if target.ExpectPattern() != nil && !target.ExpectPattern().MatchString(value) {
+		res.FailedAssertion = "expect " + target.Expect
+		res.ActualValue = truncate(value)
+	}
+	return res
+}
+
+func databaseKind(scheme string) string {
+	switch scheme {
+	case "postgres", "postgresql":
+		return "postgres"
+	case "redis", "rediss":
+		return "redis"
+	}
+	return scheme
+}
+
+func databaseLogin(target config.Target) (string, string) {
+	if target.Auth == nil {
+		return "", ""
+	}
+	return target.Auth.Username, target.Auth.Password
+}
+
+// postgresConfig builds the connection settings for the target the way libpq would for its url, trying
+// tls first (sslmode=prefer). The client certificate files libpq picks up from PGSSL* and ~/.postgresql
+// are left out, a tls profile on the target requires a verified tls connection instead
+func postgresConfig(target config.Target) (*pgx.ConnConfig, error) {
+	port := target.Port()
+	if port == "" {
+		port = "5432"
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		Host:     net.JoinHostPort(target.Hostname(), port),
+		Path:     target.Path(),
+		RawQuery: "sslmode=prefer&sslrootcert=&sslcert=&sslkey=&sslpassword=",
+	}
+	if user, password := databaseLogin(target); user != "" {
+		dsn.User = url.UserPassword(user, password)
+	}
+	cfg, err := pgx.ParseConfig(dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	if target.TLS != nil {
+		cfg.TLSConfig = targetTLSConfig(target)
+		cfg.Fallbacks = nil
+	}
+	return cfg, nil
+}
+
+func queryPostgres(ctx context.Context, target config.Target, query string, res *PingResult) (string, error) {
+	cfg, err := postgresConfig(target)
+	if err != nil {
+		return "", err
+	}
+	connectStart := time.Now()
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	res.ConnectMS = time.Since(connectStart).Milliseconds()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(context.Background())
+
+	queryStart := time.Now()
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var value string
+	if rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return "", err
+		}
+		if len(values) > 0 {
+			value = fmt.Sprint(values[0])
+		}
+	}
+	rows.Close()
+	res.QueryMS = time.Since(queryStart).Milliseconds()
+	return value, rows.Err()
+}
+
+func queryMySQL(ctx context.Context, target config.Target, query string, res *PingResult) (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = target.Host()
+	if target.Port() == "" {
+		cfg.Addr = net.JoinHostPort(target.Hostname(), "3306")
+	}
+	cfg.User, cfg.Passwd = databaseLogin(target)
+	cfg.DBName = strings.TrimPrefix(target.Path(), "/")
+	if target.TLS != nil {
+		cfg.TLS = targetTLSConfig(target)
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return "", err
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	connectStart := time.Now()
+	conn, err := db.Conn(ctx)
+	res.ConnectMS = time.Since(connectStart).Milliseconds()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	queryStart := time.Now()
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var value string
+	if rows.Next() {
+		columns, err := rows.Columns()
+		if err != nil {
+			return "", err
+		}
+		raw := make([]sql.RawBytes, len(columns))
+		dest := make([]any, len(columns))
+		for i := range raw {
+			dest[i] = &raw[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		if len(raw) > 0 {
+			value = string(raw[0])
+		}
+	}
+	rows.Close()
+	res.QueryMS = time.Since(queryStart).Milliseconds()
+	return value, rows.Err()
+}
+
+func queryRedis(ctx context.Context, target config.Target, query string, res *PingResult) (string, error) {
+	db := 0
+	if path := strings.TrimPrefix(target.Path(), "/"); path != "" {
+		var err error
+		if db, err = strconv.Atoi(path); err != nil {
+			return "", fmt.Errorf("redis database %q is not a number", path)
+		}
+	}
+	address := target.Host()
+	if target.Port() == "" {
+		address = net.JoinHostPort(target.Hostname(), "6379")
+	}
+	opts := &redis.Options{
+		Addr:            address,
+		DB:              db,
+		PoolSize:        1,
+		MaxRetries:      -1,
+		DisableIdentity: true,
+	}
+	opts.Username, opts.Password = databaseLogin(target)
+	if target.Scheme() == "rediss" {
+		opts.TLSConfig = targetTLSConfig(target)
+	}
+	//the dialer is wrapped so the connect time is known even though go-redis connects lazily
+	opts.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		connectStart := time.Now()
+		dialer := net.Dialer{}
+		conn, err := dialer.DialContext(ctx, network, addr)
+		res.ConnectMS = time.Since(connectStart).Milliseconds()
+		if err != nil || opts.TLSConfig == nil {
+			return conn, err
+		}
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, opts.TLSConfig)
+		err = tlsConn.HandshakeContext(ctx)
+		res.TLSMS = time.Since(tlsStart).Milliseconds()
+		return tlsConn, err
+	}
+	client := redis.NewClient(opts)
+	defer client.Close()
+
+	var args []any
+	for _, field := range strings.Fields(query) {
+		args = append(args, field)
+	}
+	queryStart := time.Now()
+	value, err := client.Do(ctx, args...).Result()
+	res.QueryMS = time.Since(queryStart).Milliseconds() - res.ConnectMS - res.TLSMS
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,93 @@
+package pinger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// timedLookup resolves a dns:// target and compares the answer set against the expected values
+func timedLookup(target config.Target, timeout time.Duration) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	answers, err := lookup(ctx, newResolver(target.Resolver), target.RecordType, target.Hostname())
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	slices.Sort(answers)
+	answers = slices.Compact(answers)
+	if len(target.Expected) > 0 && !slices.Equal(answers, target.Expected) {
+		res.FailedAssertion = fmt.Sprintf("%s answers %v", target.RecordType, target.Expected)
+		res.ActualValue = truncate(fmt.Sprint(answers))
+	}
+	return res
+}
+
+// newResolver returns a resolver that sends every query to the given address, or the system resolver when empty
+func newResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// lookup returns the answers for a record type, normalised the same way config normalises expected values
+func lookup(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, config.NormaliseDNSName(cname))
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range records {
+			answers = append(answers, config.NormaliseDNSName(mx.Host))
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("no %s records for %s", recordType, strings.TrimSuffix(name, "."))
+	}
+	return answers, nil
+}
@@ -0,0 +1,233 @@
+package pinger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsStub is a local stand-in dns server answering from a fixed zone over udp and tcp on the same port.
+// Answers for big.test. are marked truncated over udp so the resolver has to retry over tcp
+type dnsStub struct {
+	addr    string
+	udp     net.PacketConn
+	tcp     net.Listener
+	tcpSeen chan struct{}
+}
+
+var stubZone = map[dnsmessage.Type]map[string][]dnsmessage.ResourceBody{
+	dnsmessage.TypeA: {
+		"www.test.": {a(192, 0, 2, 1), a(192, 0, 2, 2)},
+	},
+	dnsmessage.TypeAAAA: {
+		"www.test.": {&dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}},
+	},
+	dnsmessage.TypeCNAME: {
+		"alias.test.": {&dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("WWW.test.")}},
+	},
+	dnsmessage.TypeMX: {
+		"mail.test.": {
+			&dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx1.test.")},
+			&dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("mx2.test.")},
+		},
+	},
+	dnsmessage.TypeTXT: {
+		"txt.test.": {&dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}},
+		"big.test.": {&dnsmessage.TXTResource{TXT: []string{"served over tcp"}}},
+	},
+}
+
+func a(b0, b1, b2, b3 byte) dnsmessage.ResourceBody {
+	return &dnsmessage.AResource{A: [4]byte{b0, b1, b2, b3}}
+}
+
+func newDNSStub(t *testing.T) *dnsStub {
+	t.Helper()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		t.Skipf("tcp port of the udp stub is taken: %v", err)
+	}
+	s := &dnsStub{addr: udp.LocalAddr().String(), udp: udp, tcp: tcp, tcpSeen: make(chan struct{}, 1)}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+func (s *dnsStub) serveUDP() {
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if reply, err := answer(buf[:n], true); err == nil {
+			s.udp.WriteTo(reply, peer)
+		}
+	}
+}
+
+func (s *dnsStub) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		select {
+		case s.tcpSeen <- struct{}{}:
+		default:
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				var size uint16
+				if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+					return
+				}
+				query := make([]byte, size)
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				reply, err := answer(query, false)
+				if err != nil {
+					return
+				}
+				conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(reply))))
+				conn.Write(reply)
+			}
+		}()
+	}
+}
+
+// answer builds the reply to a query from the stub zone, following cnames the way a recursive
+// server does. Unknown names get NXDOMAIN
+func answer(query []byte, udp bool) ([]byte, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil, fmt.Errorf("bad query")
+	}
+	q := msg.Questions[0]
+	name := strings.ToLower(q.Name.String())
+	reply := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, Authoritative: true, RecursionAvailable: true},
+		Questions: msg.Questions,
+	}
+	known := false
+	for _, names := range stubZone {
+		if _, ok := names[name]; ok {
+			known = true
+		}
+	}
+	switch {
+	case !known:
+		reply.RCode = dnsmessage.RCodeNameError
+	case udp && name == "big.test.":
+		reply.Truncated = true
+	default:
+		owner := q.Name
+		for _, alias := range stubZone[dnsmessage.TypeCNAME][name] {
+			if q.Type == dnsmessage.TypeCNAME {
+				break
+			}
+			reply.Answers = append(reply.Answers, resource(owner, dnsmessage.TypeCNAME, alias))
+			owner = alias.(*dnsmessage.CNAMEResource).CNAME
+			name = strings.ToLower(owner.String())
+		}
+		for _, body := range stubZone[q.Type][name] {
+			reply.Answers = append(reply.Answers, resource(owner, q.Type, body))
+		}
+	}
+	return reply.Pack()
+}
+
+func resource(name dnsmessage.Name, kind dnsmessage.Type, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Type: kind, Class: dnsmessage.ClassINET, TTL: 60},
+		Body:   body,
+	}
+}
+
+func TestTimedLookup(t *testing.T) {
+	stub := newDNSStub(t)
+	tests := []struct {
+		url        string
+		recordType string
+		expected   string
+		failed     string
+		actual     string
+		errored    bool
+	}{
+		{url: "dns://www.test", recordType: "A", expected: `["192.0.2.2", "192.0.2.1"]`},
+		{url: "dns://www.test", recordType: "", expected: `["192.0.2.1", "192.0.2.2"]`},
+		{url: "dns://www.test", recordType: "A", expected: `["192.0.2.1"]`, failed: "A answers [192.0.2.1]", actual: "[192.0.2.1 192.0.2.2]"},
+		{url: "dns://www.test", recordType: "AAAA", expected: `["2001:db8:0::1"]`},
+		{url: "dns://alias.test", recordType: "CNAME", expected: `["www.test."]`},
+		{url: "dns://alias.test", recordType: "A", expected: `["192.0.2.1", "192.0.2.2"]`},
+		{url: "dns://mail.test", recordType: "mx", expected: `["MX2.test", "mx1.test."]`},
+		{url: "dns://mail.test", recordType: "MX", expected: `["mx1.test"]`, failed: "MX answers [mx1.test]", actual: "[mx1.test mx2.test]"},
+		{url: "dns://txt.test", recordType: "TXT", expected: `["v=spf1 -all"]`},
+		{url: "dns://txt.test", recordType: "TXT"},
+		{url: "dns://big.test", recordType: "TXT", expected: `["served over tcp"]`},
+		{url: "dns://missing.test", recordType: "A", errored: true},
+		{url: "dns://mail.test", recordType: "A", errored: true},
+	}
+	for _, tt := range tests {
+		name := tt.url + " " + tt.recordType
+		expected := tt.expected
+		if expected == "" {
+			expected = "null"
+		}
+		targets := loadTargets(t, fmt.Sprintf(`{"targets":[{"url":%q,"record_type":%q,"resolver":%q,"expected":%s}]}`,
+			tt.url, tt.recordType, stub.addr, expected))
+		res := timedLookup(targets[0], 2*time.Second)
+		if tt.errored {
+			if res.Status != -1 || res.Error == "" {
+				t.Errorf("%s: got status %d error %q, want a failed lookup", name, res.Status, res.Error)
+			}
+			continue
+		}
+		if res.Error != "" {
+			t.Errorf("%s: unexpected error %s", name, res.Error)
+			continue
+		}
+		if res.FailedAssertion != tt.failed || res.ActualValue != tt.actual {
+			t.Errorf("%s: got %q %q, want %q %q", name, res.FailedAssertion, res.ActualValue, tt.failed, tt.actual)
+		}
+	}
+	select {
+	case <-stub.tcpSeen:
+	default:
+		t.Error("truncated answer was not retried over tcp")
+	}
+}
+
+func TestResolverPortDefault(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1":      "127.0.0.1:53",
+		" 127.0.0.1:53 ": "127.0.0.1:53",
+		"127.0.0.1:5353": "127.0.0.1:5353",
+		"::1":            "[::1]:53",
+		"[::1]:5353":     "[::1]:5353",
+		"ns.example":     "ns.example:53",
+	}
+	for resolver, want := range tests {
+		targets := loadTargets(t, fmt.Sprintf(`{"targets":[{"url":"dns://www.test","resolver":%q}]}`, resolver))
+		if got := targets[0].Resolver; got != want {
+			t.Errorf("resolver %q = %q, want %q", resolver, got, want)
+		}
+	}
+}
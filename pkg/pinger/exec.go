@@ -0,0 +1,168 @@
+package pinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// plugin output past this is dropped, matching the nagios default buffer
+const maxPluginOutput = 8192
+
+// how long a cancelled command gets to exit after SIGTERM before it is killed
+const killGrace = 2 * time.Second
+
+// plugin states indexed by exit code
+var checkStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// Perfdata is one label=value;warn;crit;min;max entry of plugin performance data
+type Perfdata struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	UOM   string  `json:"uom,omitempty"`
+	Warn  string  `json:"warn,omitempty"`
+	Crit  string  `json:"crit,omitempty"`
+	Min   string  `json:"min,omitempty"`
+	Max   string  `json:"max,omitempty"`
+}
+
+// timedExec runs a nagios compatible plugin and maps its exit code to a check state, the command is
+// killed when the timeout passes or the monitor shuts down
+func timedExec(target config.Target, timeout time.Duration, finish context.Context) PingResult {
+	ctx, cancel := context.WithTimeout(finish, timeout)
+	defer cancel()
+	stdout := &cappedBuffer{limit: maxPluginOutput}
+	stderr := &cappedBuffer{limit: maxPluginOutput}
+	cmd := exec.CommandContext(ctx, target.Command[0], target.Command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = killGrace
+	terminateOnCancel(cmd)
+
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	err := cmd.Run()
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if ctx.Err() != nil {
+		res.Error = fmt.Sprintf("command did not finish within %s", timeout)
+		res.Status = -1
+		return res
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	code := cmd.ProcessState.ExitCode()
+	if code < 0 {
+		res.Error = cmd.ProcessState.String()
+		res.Status = -1
+		return res
+	}
+	res.Status = code
+	res.CheckState = checkStates[min(code, len(checkStates)-1)]
+	output := stdout.String()
+	if strings.TrimSpace(output) == "" {
+		output = stderr.String()
+	}
+	res.StatusLine, res.Perfdata = parsePluginOutput(output)
+	return res
+}
+
+// parsePluginOutput splits plugin output into the status line and perfdata, which comes after the
+// first | of the first line and the first | of the long output
+func parsePluginOutput(output string) (string, []Perfdata) {
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	status, perf, _ := strings.Cut(lines[0], "|")
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			perf += " " + line
+			continue
+		}
+		if _, after, ok := strings.Cut(line, "|"); ok {
+			perf += " " + after
+			inPerf = true
+		}
+	}
+	return truncate(strings.TrimSpace(status)), parsePerfdata(perf)
+}
+
+// parsePerfdata reads space separated 'label'=value[uom];[warn];[crit];[min];[max] entries,
+// skipping any that are malformed or have an unknown value
+func parsePerfdata(text string) []Perfdata {
+	var entries []Perfdata
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		var label, field string
+		if strings.HasPrefix(rest, "'") {
+			end := strings.Index(rest[1:], "'=")
+			if end < 0 {
+				break
+			}
+			label, rest = strings.ReplaceAll(rest[1:end+1], "''", "'"), rest[end+3:]
+			field, rest, _ = strings.Cut(rest, " ")
+		} else {
+			//a token without = has no value and is skipped on its own
+			var token string
+			token, rest, _ = strings.Cut(rest, " ")
+			label, field, _ = strings.Cut(token, "=")
+		}
+		rest = strings.TrimSpace(rest)
+		if entry, ok := parsePerfField(label, field); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func parsePerfField(label, field string) (Perfdata, bool) {
+	parts := strings.Split(field, ";")
+	for len(parts) < 5 {
+		parts = append(parts, "")
+	}
+	number := strings.TrimRightFunc(parts[0], func(r rune) bool {
+		return !strings.ContainsRune("0123456789.", r)
+	})
+	value, err := strconv.ParseFloat(number, 64)
+	if label == "" || err != nil {
+		return Perfdata{}, false
+	}
+	return Perfdata{
+		Label: label,
+		Value: value,
+		UOM:   parts[0][len(number):],
+		Warn:  parts[1],
+		Crit:  parts[2],
+		Min:   parts[3],
+		Max:   parts[4],
+	}, true
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest
+type cappedBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(len(p), room)]...)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	return string(b.buf)
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf
+}
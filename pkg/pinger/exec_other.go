@@ -0,0 +1,8 @@
+//go:build !unix
+
+package pinger
+
+import "os/exec"
+
+// terminateOnCancel keeps the default of killing the command outright, there are no process groups to signal
+func terminateOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,103 @@
+package pinger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		status string
+		perf   []Perfdata
+	}{
+		{
+			name:   "status only",
+			output: "DISK OK - free space: / 3326 MB (56%)\n",
+			status: "DISK OK - free space: / 3326 MB (56%)",
+		},
+		{
+			name:   "full field",
+			output: "DISK OK | /=2643MB;5948;5958;0;5968",
+			status: "DISK OK",
+			perf:   []Perfdata{{Label: "/", Value: 2643, UOM: "MB", Warn: "5948", Crit: "5958", Min: "0", Max: "5968"}},
+		},
+		{
+			name:   "several fields and uoms",
+			output: "OK|time=0.032s;;;0.000 size=512B load1=0.5 pct=99.5% hits=12c",
+			status: "OK",
+			perf: []Perfdata{
+				{Label: "time", Value: 0.032, UOM: "s", Min: "0.000"},
+				{Label: "size", Value: 512, UOM: "B"},
+				{Label: "load1", Value: 0.5},
+				{Label: "pct", Value: 99.5, UOM: "%"},
+				{Label: "hits", Value: 12, UOM: "c"},
+			},
+		},
+		{
+			name:   "empty warn and crit",
+			output: "OK | rta=1.2ms;;;0; pl=0%;20;;",
+			status: "OK",
+			perf: []Perfdata{
+				{Label: "rta", Value: 1.2, UOM: "ms", Min: "0"},
+				{Label: "pl", Value: 0, UOM: "%", Warn: "20"},
+			},
+		},
+		{
+			name:   "quoted labels",
+			output: "OK | 'free space'=10GB 'it''s'=1 'a=b'=2",
+			status: "OK",
+			perf: []Perfdata{
+				{Label: "free space", Value: 10, UOM: "GB"},
+				{Label: "it's", Value: 1},
+				{Label: "a=b", Value: 2},
+			},
+		},
+		{
+			name:   "ranges are kept as text",
+			output: "WARNING | temp=41;@10:20;~:45",
+			status: "WARNING",
+			perf:   []Perfdata{{Label: "temp", Value: 41, Warn: "@10:20", Crit: "~:45"}},
+		},
+		{
+			name: "long output",
+			output: "DISK WARNING - free space: /tmp 120 MB | /tmp=120MB;100;50\n" +
+				"/ 3000 MB free\n" +
+				"/var 200 MB free | /=3000MB;;\n" +
+				"/var=200MB;;\n",
+			status: "DISK WARNING - free space: /tmp 120 MB",
+			perf: []Perfdata{
+				{Label: "/tmp", Value: 120, UOM: "MB", Warn: "100", Crit: "50"},
+				{Label: "/", Value: 3000, UOM: "MB"},
+				{Label: "/var", Value: 200, UOM: "MB"},
+			},
+		},
+		{
+			name:   "long output without perfdata",
+			output: "OK\nline two\nline three",
+			status: "OK",
+		},
+		{
+			name:   "unknown and malformed values are skipped",
+			output: "OK | a=U;1;2 b=;1 =5 c=7 novalue 'unterminated=1",
+			status: "OK",
+			perf:   []Perfdata{{Label: "c", Value: 7}},
+		},
+		{
+			name:   "windows line endings",
+			output: "OK | x=1\r\n",
+			status: "OK",
+			perf:   []Perfdata{{Label: "x", Value: 1}},
+		},
+	}
+	for _, tt := range tests {
+		status, perf := parsePluginOutput(tt.output)
+		if status != tt.status {
+			t.Errorf("%s: status %q, want %q", tt.name, status, tt.status)
+		}
+		if !reflect.DeepEqual(perf, tt.perf) {
+			t.Errorf("%s: perfdata\n got %+v\nwant %+v", tt.name, perf, tt.perf)
+		}
+	}
+}
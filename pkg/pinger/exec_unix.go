@@ -0,0 +1,17 @@
+//go:build unix
+
+package pinger
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// terminateOnCancel runs the command in its own process group so SIGTERM on cancel also reaches
+// anything the plugin started
+func terminateOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+}
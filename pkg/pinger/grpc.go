@@ -0,0 +1,75 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// grpcConns keeps one connection per grpc target, grpc reconnects on its own between checks
+var grpcConns sync.Map
+
+func grpcConn(target config.Target) (*grpc.ClientConn, error) {
+	if conn, ok := grpcConns.Load(target.URL); ok {
+		return conn.(*grpc.ClientConn), nil
+	}
+	creds := insecure.NewCredentials()
+	if target.Scheme() == "grpcs" {
+		cfg := &tls.Config{}
+		if target.TLS != nil {
+			cfg = target.TLS.TLSConfig()
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	conn, err := grpc.NewClient(target.Host(),
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(userAgent))
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := grpcConns.LoadOrStore(target.URL, conn)
+	if loaded {
+		conn.Close()
+	}
+	return actual.(*grpc.ClientConn), nil
+}
+
+// timedHealthCheck calls grpc.health.v1.Health/Check, anything other than SERVING fails the ping
+func timedHealthCheck(target config.Target, timeout time.Duration) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	conn, err := grpcConn(target)
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	var p peer.Peer
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx,
+		&healthpb.HealthCheckRequest{Service: target.Service},
+		grpc.Peer(&p))
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		res.TLS = tlsInfoFromState(&info.State)
+	}
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		res.FailedAssertion = "status SERVING"
+		res.ActualValue = resp.GetStatus().String()
+	}
+	return res
+}
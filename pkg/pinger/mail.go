@@ -0,0 +1,183 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+var mailPorts = map[string]string{
+	"smtp": "25", "smtps": "465",
+	"imap": "143", "imaps": "993",
+	"pop3": "110", "pop3s": "995",
+}
+
+// timedMailCheck connects to an smtp, imap or pop3 server (implicit tls for the s variants), validates
+// the greeting and optionally upgrades with STARTTLS. Connect, tls and greeting times go in the phases
+func timedMailCheck(target config.Target, timeout time.Duration) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	fail := func(err error) PingResult {
+		res.ResponseMS = time.Since(start).Milliseconds()
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	scheme := target.Scheme()
+	protocol := strings.TrimSuffix(scheme, "s")
+	address := target.Host()
+	if target.Port() == "" {
+		address = net.JoinHostPort(target.Hostname(), mailPorts[scheme])
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	res.ConnectMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if scheme != protocol { //implicit tls
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, targetTLSConfig(target))
+		err := tlsConn.HandshakeContext(ctx)
+		res.TLSMS = time.Since(tlsStart).Milliseconds()
+		if err != nil {
+			res.TLS = tlsInfoFromError(err)
+			return fail(err)
+		}
+		state := tlsConn.ConnectionState()
+		res.TLS = tlsInfoFromState(&state)
+		conn = tlsConn
+	}
+
+	text := textproto.NewConn(conn)
+	greeting, err := readGreeting(text, protocol)
+	res.TTFBMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.ActualValue = truncate(greeting)
+		return fail(err)
+	}
+	if target.ExpectPattern() != nil && !target.ExpectPattern().MatchString(greeting) {
+		res.FailedAssertion = "expect " + target.Expect
+		res.ActualValue = truncate(greeting)
+	}
+
+	if target.StartTLS && scheme == protocol {
+		if err := startTLS(text, protocol); err != nil {
+			return fail(fmt.Errorf("starttls: %w", err))
+		}
+		tlsStart := time.Now()
+		tlsConn := tls.Client(conn, targetTLSConfig(target))
+		err := tlsConn.HandshakeContext(ctx)
+		res.TLSMS = time.Since(tlsStart).Milliseconds()
+		if err != nil {
+			res.TLS = tlsInfoFromError(err)
+			return fail(err)
+		}
+		state := tlsConn.ConnectionState()
+		res.TLS = tlsInfoFromState(&state)
+		text = textproto.NewConn(tlsConn)
+	}
+	quit(text, protocol)
+	res.ResponseMS = time.Since(start).Milliseconds()
+	return res
+}
+
+// readGreeting reads the server banner, returning it with an error when it is not a ready greeting
+func readGreeting(text *textproto.Conn, protocol string) (string, error) {
+	switch protocol {
+	case "smtp":
+		_, msg, err := text.ReadResponse(220)
+		if err != nil {
+			return msg, fmt.Errorf("smtp greeting: %w", err)
+		}
+		return "220 " + msg, nil
+	case "imap":
+		line, err := text.ReadLine()
+		if err != nil {
+			return line, fmt.Errorf("imap greeting: %w", err)
+		}
+		if !strings.HasPrefix(line, "* OK") && !strings.HasPrefix(line, "* PREAUTH") {
+			return line, fmt.Errorf("imap greeting is not OK")
+		}
+		return line, nil
+	default:
+		line, err := text.ReadLine()
+		if err != nil {
+			return line, fmt.Errorf("pop3 greeting: %w", err)
+		}
+		if !strings.HasPrefix(line, "+OK") {
+			return line, fmt.Errorf("pop3 greeting is not +OK")
+		}
+		return line, nil
+	}
+}
+
+// startTLS asks the server to upgrade the connection, the caller then does the handshake
+func startTLS(text *textproto.Conn, protocol string) error {
+	switch protocol {
+	case "smtp":
+		if _, err := text.Cmd("EHLO gositemonitor"); err != nil {
+			return err
+		}
+		if _, _, err := text.ReadResponse(250); err != nil {
+			return err
+		}
+		if _, err := text.Cmd("STARTTLS"); err != nil {
+			return err
+		}
+		_, _, err := text.ReadResponse(220)
+		return err
+	case "imap":
+		if _, err := text.Cmd("a1 STARTTLS"); err != nil {
+			return err
+		}
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(line, "a1 ") {
+				if !strings.HasPrefix(line, "a1 OK") {
+					return fmt.Errorf("%s", line)
+				}
+				return nil
+			}
+		}
+	default:
+		if _, err := text.Cmd("STLS"); err != nil {
+			return err
+		}
+		line, err := text.ReadLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "+OK") {
+			return fmt.Errorf("%s", line)
+		}
+		return nil
+	}
+}
+
+// quit ends the session politely, errors are ignored since the check already passed
+func quit(text *textproto.Conn, protocol string) {
+	switch protocol {
+	case "imap":
+		text.Cmd("a2 LOGOUT")
+	default:
+		text.Cmd("QUIT")
+	}
+	text.ReadLine()
+}
@@ -0,0 +1,111 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newMailStub serves a scripted session to every connection. "S:" lines are sent, "C:" lines must be
+// the start of the next command from the client and "TLS" upgrades the connection as the server
+func newMailStub(t *testing.T, cert tls.Certificate, script []string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveMailScript(conn, cert, script)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveMailScript(conn net.Conn, cert tls.Certificate, script []string) {
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	text := textproto.NewConn(conn)
+	for _, step := range script {
+		switch {
+		case step == "TLS":
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+		case strings.HasPrefix(step, "S:"):
+			text.PrintfLine("%s", step[2:])
+		case strings.HasPrefix(step, "C:"):
+			line, err := text.ReadLine()
+			if err != nil || !strings.HasPrefix(line, step[2:]) {
+				return
+			}
+		}
+	}
+}
+
+func TestTimedMailCheck(t *testing.T) {
+	cert, caFile := selfSigned(t, "mail.test")
+	smtpSession := []string{"S:220-mail.test ESMTP", "S:220 ready", "C:EHLO", "S:250-mail.test", "S:250 STARTTLS",
+		"C:STARTTLS", "S:220 go ahead", "TLS", "C:QUIT", "S:221 bye"}
+	tests := []struct {
+		name     string
+		scheme   string
+		starttls bool
+		expect   string
+		script   []string
+		err      string
+		failed   string
+		tls      bool
+	}{
+		{name: "smtp multi-line greeting", scheme: "smtp", script: []string{"S:220-mail.test ESMTP", "S:220 ready", "C:QUIT", "S:221 bye"}},
+		{name: "smtp starttls", scheme: "smtp", starttls: true, script: smtpSession, tls: true},
+		{name: "smtp expect", scheme: "smtp", expect: "ESMTP", script: smtpSession},
+		{name: "smtp expect mismatch", scheme: "smtp", expect: "Postfix", script: smtpSession, failed: "expect Postfix"},
+		{name: "smtp starttls refused", scheme: "smtp", starttls: true,
+			script: []string{"S:220 ready", "C:EHLO", "S:250 mail.test", "C:STARTTLS", "S:454 TLS not available"}, err: "starttls: 454"},
+		{name: "smtp bad banner", scheme: "smtp", script: []string{"S:554 no service"}, err: "smtp greeting"},
+		{name: "smtps", scheme: "smtps", script: []string{"TLS", "S:220 ready", "C:QUIT", "S:221 bye"}, tls: true},
+		{name: "imap", scheme: "imap", script: []string{"S:* OK IMAP4rev1 ready", "C:a2 LOGOUT", "S:* BYE"}},
+		{name: "imap starttls", scheme: "imap", starttls: true,
+			script: []string{"S:* OK IMAP4rev1 ready", "C:a1 STARTTLS", "S:* CAPABILITY IMAP4rev1", "S:a1 OK begin TLS", "TLS", "C:a2 LOGOUT", "S:* BYE"}, tls: true},
+		{name: "imap starttls refused", scheme: "imap", starttls: true,
+			script: []string{"S:* OK ready", "C:a1 STARTTLS", "S:a1 NO not now"}, err: "a1 NO not now"},
+		{name: "imap bad banner", scheme: "imap", script: []string{"S:* BYE go away"}, err: "imap greeting is not OK"},
+		{name: "imaps", scheme: "imaps", script: []string{"TLS", "S:* PREAUTH ready", "C:a2 LOGOUT", "S:* BYE"}, tls: true},
+		{name: "pop3", scheme: "pop3", script: []string{"S:+OK POP3 ready", "C:QUIT", "S:+OK bye"}},
+		{name: "pop3 stls", scheme: "pop3", starttls: true,
+			script: []string{"S:+OK POP3 ready", "C:STLS", "S:+OK begin TLS", "TLS", "C:QUIT", "S:+OK bye"}, tls: true},
+		{name: "pop3 bad banner", scheme: "pop3", script: []string{"S:-ERR busy"}, err: "pop3 greeting is not +OK"},
+		{name: "silent server", scheme: "pop3", script: nil, err: "pop3 greeting"},
+	}
+	for _, tt := range tests {
+		addr := newMailStub(t, cert, tt.script)
+		targets := loadTargets(t, fmt.Sprintf(`{"targets":[{"url":"%s://%s","starttls":%t,"expect":%q,"tls":{"ca_file":%q,"server_name":"mail.test"}}]}`,
+			tt.scheme, addr, tt.starttls, tt.expect, caFile))
+		res := timedMailCheck(targets[0], time.Second)
+		if tt.err != "" {
+			if res.Status != -1 || !strings.Contains(res.Error, tt.err) {
+				t.Errorf("%s: got status %d error %q, want %q", tt.name, res.Status, res.Error, tt.err)
+			}
+			continue
+		}
+		if res.Error != "" || res.FailedAssertion != tt.failed {
+			t.Errorf("%s: got error %q assertion %q, want assertion %q", tt.name, res.Error, res.FailedAssertion, tt.failed)
+		}
+		if (res.TLS != nil) != tt.tls {
+			t.Errorf("%s: got tls %v, want %v", tt.name, res.TLS != nil, tt.tls)
+		}
+	}
+}
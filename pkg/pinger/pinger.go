@@ -1,27 +1,119 @@
 package pinger
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
 	// "github.com/sairamkumarm/gositemonitor/pkg/logger"
 )
 
+// upper bound on how much of a body is read
+const maxBodyBytes = 4 << 20
+
+// sent unless a target sets its own User-Agent header
+const userAgent = "GoSiteMonitor"
+
 type PingResult struct {
-	URL          string    `json:"url"`
-	Status       int       `json:"status"`
-	ResponseMS   int64     `json:"response_time_ms"`
-	Error        string    `json:"error,omitempty"`
-	TimestampUTC time.Time `json:"timestamp_utc"`
-	WorkerID     int       `json:"worker_id"`
+	URL              string         `json:"url"`
+	Variant          string         `json:"variant,omitempty"`
+	IP               string         `json:"ip,omitempty"`
+	Status           int            `json:"status"`
+	ResponseMS       int64          `json:"response_time_ms"`
+	Error            string         `json:"error,omitempty"`
+	AuthError        string         `json:"auth_error,omitempty"`
+	ProxyError       string         `json:"proxy_error,omitempty"`
+	Proxy            string         `json:"proxy,omitempty"`
+	FailedAssertion  string         `json:"failed_assertion,omitempty"`
+	ActualValue      string         `json:"actual_value,omitempty"`
+	RedirectChain    []string       `json:"redirect_chain,omitempty"`
+	FinalURL         string         `json:"final_url,omitempty"`
+	HandshakeMS      int64          `json:"handshake_ms,omitempty"`
+	RoundTripMS      int64          `json:"round_trip_ms,omitempty"`
+	QueryMS          int64          `json:"query_ms,omitempty"`
+	Steps            []StepResult   `json:"steps,omitempty"`
+	CheckState       string         `json:"check_state,omitempty"`
+	StatusLine       string         `json:"status_line,omitempty"`
+	Perfdata         []Perfdata     `json:"perfdata,omitempty"`
+	ContentHash      string         `json:"content_hash,omitempty"`
+	TransferBytes    int64          `json:"transfer_bytes,omitempty"`
+	BodyBytes        int64          `json:"body_bytes,omitempty"`
+	ContentEncoding  string         `json:"content_encoding,omitempty"`
+	BudgetViolations []string       `json:"budget_violations,omitempty"`
+	AuditFailures    []AuditFailure `json:"audit_failures,omitempty"`
+	Protocol         string         `json:"protocol,omitempty"`
+	ALPN             string         `json:"alpn,omitempty"`
+	TLS              *TLSInfo       `json:"tls,omitempty"`
+	Phases
+	TimestampUTC   time.Time `json:"timestamp_utc"`
+	WorkerID       int       `json:"worker_id"`
+	statusExpected bool      //status was listed in the target's expected_status
+	content        []byte    //normalised body of content tracked targets
+	audited        bool      //the target's security audit ran on this result
+}
+
+// Content returns the normalised body that ContentHash was taken over
+func (r PingResult) Content() []byte {
+	return r.content
+}
+
+// Audited reports whether the result carries a security audit, an audit with no failures means every rule passed
+func (r PingResult) Audited() bool {
+	return r.audited
 }
 
-func timedGet(url string, timeout time.Duration, client *http.Client) PingResult {
+// Key names the series a result belongs to, each variant of a url is analysed on its own
+func (r PingResult) Key() string {
+	if r.Variant == "" {
+		return r.URL
+	}
+	return r.URL + " (" + r.Variant + ")"
+}
+
+// Failed reports whether the result counts towards an outage
+func (r PingResult) Failed() bool {
+	return r.Status == -1 || (r.Status >= 400 && !r.statusExpected) || r.FailedAssertion != "" ||
+		r.CheckState == "CRITICAL" || r.CheckState == "UNKNOWN"
+}
+
+// check runs the probe matching the target's scheme
+func check(target config.Target, timeout time.Duration, client *http.Client, finish context.Context) PingResult {
+	switch target.Scheme() {
+	case "tcp":
+		return timedDial(target, timeout)
+	case "dns":
+		return timedLookup(target, timeout)
+	case "grpc", "grpcs":
+		return timedHealthCheck(target, timeout)
+	case "ws", "wss":
+		return timedWebSocket(target, timeout, client)
+	case "transaction":
+		return timedTransaction(target, timeout, client)
+	case "smtp", "smtps", "imap", "imaps", "pop3", "pop3s":
+		return timedMailCheck(target, timeout)
+	case "postgres", "postgresql", "mysql", "redis", "rediss":
+		return timedQuery(target, timeout)
+	case "exec":
+		return timedExec(target, timeout, finish)
+	case "script":
+		return timedScript(target, timeout, client)
+	default:
+		return timedGet(target, timeout, client)
+	}
+}
+
+func timedGet(target config.Target, timeout time.Duration, client *http.Client) PingResult {
+	url := target.URL
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := newRequest(ctx, url, target.Request)
 	if err != nil {
 		return PingResult{
 			URL:          url,
@@ -30,47 +122,165 @@ func timedGet(url string, timeout time.Duration, client *http.Client) PingResult
 			TimestampUTC: time.Now().UTC(),
 		}
 	}
+	client = clientFor(target, client)
+	if err := authorize(ctx, req, target.Auth, client); err != nil {
+		//the target was never contacted, so this is not counted as a failed ping
+		return PingResult{
+			URL:          url,
+			AuthError:    err.Error(),
+			TimestampUTC: time.Now().UTC(),
+		}
+	}
+	//compression is asked for here rather than by the transport, so the encoded size can be counted
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
 	start := time.Now()
+	trace := newPhaseTrace(start)
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()))
 	res := PingResult{URL: url, TimestampUTC: start.UTC()}
-	resp, err := client.Do(req)
+	if target.ProxyURL() != nil {
+		res.Proxy = proxyLabel(target.ProxyURL())
+	}
+	redirects := &redirectLog{}
+	resp, err := withRedirects(client, target.Redirects, redirects).Do(req)
 	res.ResponseMS = time.Since(start).Milliseconds()
 	if err != nil {
 		// fmt.Println("Request Failed, ", err)
-		res.Error = err.Error()
-		res.Status = -1
+		requestError(&res, err)
+		res.TLS = tlsInfoFromError(err)
+		res.Phases = trace.phases(time.Time{})
+		res.IP = targetIP(target, trace)
 		return res
 	}
 	defer resp.Body.Close()
+	if err := proxyAuthRequired(target.ProxyURL(), resp); err != nil {
+		requestError(&res, err)
+		return res
+	}
 	res.Status = resp.StatusCode
+	res.IP = targetIP(target, trace)
+	res.Protocol = resp.Proto
+	if resp.TLS != nil {
+		res.ALPN = resp.TLS.NegotiatedProtocol
+	}
+	res.TLS = tlsInfoFromState(resp.TLS)
+	res.RedirectChain = redirects.chain
+	res.FinalURL = resp.Request.URL.String()
+	if res.Status == http.StatusUnauthorized {
+		forgetToken(target.Auth)
+	}
+	switch {
+	case redirects.violation != "":
+		res.FailedAssertion, res.ActualValue = redirects.violation, redirects.actual
+	case target.ExpectedFinalURL != "" && res.FinalURL != target.ExpectedFinalURL:
+		res.FailedAssertion, res.ActualValue = "final url "+target.ExpectedFinalURL, res.FinalURL
+	}
+	//the body is always read in full so transfer time and page weight are measured
+	body, err := readBody(resp, &res)
+	res.Phases = trace.phases(time.Now())
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	//a body left in an encoding that cannot be decoded here is compressed, so it is neither matched nor hashed
+	checks := target.Checks
+	undecoded := !decodable(res.ContentEncoding)
+	if undecoded {
+		checks = config.Checks{}
+	}
+	checkResponse(&res, body, target.ExpectedStatus, checks)
+	if undecoded && res.FailedAssertion == "" && (!target.Checks.Empty() || target.ContentTracking != nil) {
+		res.FailedAssertion = fmt.Sprintf("cannot decode %s body", res.ContentEncoding)
+	}
+	//budgets and the audit only mean something for a response the target served as intended
+	if !res.Failed() {
+		checkBudget(&res, target.Budget)
+		if target.Audit != nil {
+			audit(ctx, &res, resp, target)
+		}
+	}
+	if target.ContentTracking != nil && !undecoded {
+		res.content = normaliseContent(body, target.ContentTracking)
+		res.ContentHash = contentHash(res.content)
+	}
 	return res
 }
 
-func Worker(id int, jobs chan string, results chan PingResult, permits chan struct{}, timeoutsecs time.Duration, client *http.Client, finish context.Context, wg *sync.WaitGroup) {
+// checkResponse applies the expected status and body assertions, keeping any earlier failure
+func checkResponse(res *PingResult, body []byte, expectedStatus []int, checks config.Checks) {
+	if res.FailedAssertion == "" && len(expectedStatus) > 0 {
+		res.statusExpected = slices.Contains(expectedStatus, res.Status)
+		if !res.statusExpected {
+			res.FailedAssertion = fmt.Sprintf("status in %v", expectedStatus)
+		}
+	}
+	if res.FailedAssertion == "" && !checks.Empty() {
+		res.FailedAssertion = checkBody(body, checks.Assertions)
+		if res.FailedAssertion == "" && len(checks.JSONExprs()) > 0 {
+			res.FailedAssertion, res.ActualValue = checkJSON(body, checks.JSONExprs())
+		}
+	}
+}
+
+// newRequest builds the request described by the target's method, headers and body
+func newRequest(ctx context.Context, url string, spec config.Request) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, spec.Method, url, bytes.NewReader(spec.Payload()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range spec.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+func Worker(id int, jobs chan config.Target, results chan PingResult, permits chan struct{}, timeoutsecs time.Duration, client *http.Client, finish context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for {
 		select {
 		case <-finish.Done():
 			fmt.Println("Deactivating Worker-", id)
 			return
-		case url, ok := <-jobs:
+		case target, ok := <-jobs:
 			if !ok {
 				break
 			}
-			select {
-			case <-finish.Done():
-				return
-			case _, ok = <-permits:
-				if !ok {
-					break
+			list, err := variants(target, timeoutsecs)
+			if err != nil {
+				res := PingResult{URL: target.URL, Status: -1, Error: err.Error(), TimestampUTC: time.Now().UTC(), WorkerID: id}
+				select {
+				case <-finish.Done():
+					return
+				case results <- res:
 				}
 			}
-			res := timedGet(url, timeoutsecs, client)
-			res.WorkerID = id
-			select {
-			case <-finish.Done():
-				return
-			case results <- res:
-				//nothing just enqueue
+			//every variant takes its own permit, so expanding a target does not multiply its rate. A transaction
+			//or script is one permit however many requests it sends, their steps and max_steps bound those
+			for _, v := range list {
+				select {
+				case <-finish.Done():
+					return
+				case _, ok = <-permits:
+					if !ok {
+						break
+					}
+				}
+				res := check(v.target, timeoutsecs, client, finish)
+				res.Variant = v.name
+				res.WorkerID = id
+				select {
+				case <-finish.Done():
+					return
+				case results <- res:
+					//nothing just enqueue
+				}
 			}
 		}
 	}
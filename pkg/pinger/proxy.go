@@ -0,0 +1,88 @@
+package pinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// proxyRefused is a request the proxy answered itself instead of passing it on, a CONNECT that did not
+// get a 200 or a forwarded request it wants credentials for
+type proxyRefused struct {
+	method string
+	status string
+}
+
+func (e *proxyRefused) Error() string {
+	return "proxy refused " + e.method + ": " + e.status
+}
+
+// SetProxy sends the transport's requests through the proxy, or directly when it is nil
+func SetProxy(transport *http.Transport, proxy *url.URL) {
+	transport.Proxy = nil
+	transport.OnProxyConnectResponse = nil
+	if proxy == nil {
+		return
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	//the transport reports a refused CONNECT as a bare status text, typing it keeps it apart from target errors
+	transport.OnProxyConnectResponse = func(_ context.Context, _ *url.URL, _ *http.Request, resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return &proxyRefused{method: http.MethodConnect, status: resp.Status}
+		}
+		return nil
+	}
+}
+
+// proxyAuthRequired turns the 407 an http proxy answers a plain http request with into a proxy error.
+// https requests are tunnelled and a refused CONNECT already fails, but plain http requests are forwarded
+// and the proxy's refusal arrives as their response
+func proxyAuthRequired(proxy *url.URL, resp *http.Response) error {
+	if proxy == nil || !strings.HasPrefix(proxy.Scheme, "http") {
+		return nil
+	}
+	if resp.StatusCode != http.StatusProxyAuthRequired || resp.Request.URL.Scheme != "http" {
+		return nil
+	}
+	return &proxyRefused{method: resp.Request.Method, status: resp.Status}
+}
+
+// isProxyError reports whether a request failed between the monitor and its proxy, covering
+// dialing the proxy, its handshake and authentication, and a refused tunnel
+func isProxyError(err error) bool {
+	var refused *proxyRefused
+	if errors.As(err, &refused) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || strings.HasPrefix(opErr.Op, "socks "))
+}
+
+// requestError records a failed request, proxy failures go to ProxyError since the target was never reached
+func requestError(res *PingResult, err error) {
+	if isProxyError(err) {
+		res.ProxyError = err.Error()
+		return
+	}
+	res.Error = err.Error()
+	res.Status = -1
+}
+
+// proxyLabel names a proxy without its credentials
+func proxyLabel(proxy *url.URL) string {
+	return fmt.Sprintf("%s://%s", proxy.Scheme, proxy.Host)
+}
+
+// targetIP is the ip the request connected to, unknown when it went through a proxy
+func targetIP(target config.Target, trace *phaseTrace) string {
+	if target.ProxyURL() != nil {
+		return ""
+	}
+	return trace.ip()
+}
@@ -0,0 +1,56 @@
+package pinger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyAuthRequired(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") == "" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(proxy.Close)
+	withAuth := strings.Replace(proxy.URL, "http://", "http://u:p@", 1)
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "http", target: `{"url":"http://site.test/"}`},
+		{name: "transaction", target: `{"url":"transaction://login","steps":[{"url":"http://site.test/"}]}`},
+		{name: "script", target: `{"url":"script://get","script":{"source":"http.get(\"http://site.test/\")"}}`},
+	}
+	for _, tt := range tests {
+		for proxyURL, refused := range map[string]bool{proxy.URL: true, withAuth: false} {
+			targets := loadTargets(t, fmt.Sprintf(`{"proxy":%q,"targets":[%s]}`, proxyURL, tt.target))
+			client := &http.Client{Transport: &http.Transport{}, Timeout: time.Second}
+			SetProxy(client.Transport.(*http.Transport), targets[0].ProxyURL())
+			var res PingResult
+			switch tt.name {
+			case "http":
+				res = timedGet(targets[0], time.Second, client)
+			case "transaction":
+				res = timedTransaction(targets[0], time.Second, client)
+			case "script":
+				res = timedScript(targets[0], time.Second, client)
+			}
+			if refused {
+				if !strings.Contains(res.ProxyError, "407") || res.Status == http.StatusProxyAuthRequired || res.Error != "" {
+					t.Errorf("%s: got status %d error %q proxy error %q, want a proxy error", tt.name, res.Status, res.Error, res.ProxyError)
+				}
+				continue
+			}
+			if res.ProxyError != "" {
+				t.Errorf("%s: unexpected proxy error %q with credentials", tt.name, res.ProxyError)
+			}
+		}
+	}
+}
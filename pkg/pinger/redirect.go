@@ -0,0 +1,44 @@
+package pinger
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// redirectLog records the hops of one request and the first policy violation, if any
+type redirectLog struct {
+	chain     []string
+	violation string
+	actual    string
+}
+
+var defaultRedirectPolicy = config.RedirectPolicy{Policy: "follow", MaxHops: config.DefaultMaxRedirects}
+
+// withRedirects returns a copy of the client, sharing its transport, that logs redirects and
+// applies the target's policy. A violation stops at the last response instead of erroring so
+// the status is still recorded
+func withRedirects(client *http.Client, policy *config.RedirectPolicy, log *redirectLog) *http.Client {
+	if policy == nil {
+		policy = &defaultRedirectPolicy
+	}
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		switch {
+		case policy.Policy == "none":
+			return http.ErrUseLastResponse
+		case len(via) > policy.MaxHops:
+			log.violation = fmt.Sprintf("at most %d redirects", policy.MaxHops)
+			log.actual = req.URL.String()
+			return http.ErrUseLastResponse
+		case !policy.AllowsHost(req.URL.Hostname()):
+			log.violation = fmt.Sprintf("redirect hosts %v", policy.AllowedHosts)
+			log.actual = req.URL.String()
+			return http.ErrUseLastResponse
+		}
+		log.chain = append(log.chain, req.URL.String())
+		return nil
+	}
+	return &c
+}
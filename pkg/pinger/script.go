@@ -0,0 +1,193 @@
+package pinger
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// scriptFailure is raised by fail() in a script, it fails the check the way an assertion does
+type scriptFailure struct {
+	message string
+	actual  string
+}
+
+func (f *scriptFailure) Error() string {
+	return f.message
+}
+
+// scriptRun is the state a single script execution shares with its builtins
+type scriptRun struct {
+	ctx        context.Context
+	client     *http.Client
+	proxy      *url.URL
+	status     int    //status of the latest http response
+	statusLine string //last line the script printed
+}
+
+// timedScript runs the starlark program of a script:// target. It gets the same overall timeout as
+// an http ping and is cancelled once it runs past it or its step limit
+func timedScript(target config.Target, timeout time.Duration, client *http.Client) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	run := &scriptRun{ctx: ctx, client: clientFor(target, client), proxy: target.ProxyURL()}
+	thread := &starlark.Thread{
+		Name: target.URL,
+		Print: func(_ *starlark.Thread, msg string) {
+			run.statusLine = msg
+		},
+	}
+	thread.SetMaxExecutionSteps(target.Script.MaxSteps)
+	stop := context.AfterFunc(ctx, func() { thread.Cancel("timed out") })
+	defer stop()
+
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	_, err := starlark.ExecFileOptions(config.ScriptOptions, thread, target.Script.Filename(), target.Script.Program(), run.predeclared())
+	res.ResponseMS = time.Since(start).Milliseconds()
+	res.Status = run.status
+	res.StatusLine = truncate(run.statusLine)
+	var failure *scriptFailure
+	switch {
+	case err == nil:
+		//the script decides whether the responses it saw are acceptable
+		res.statusExpected = true
+	case errors.As(err, &failure):
+		res.FailedAssertion, res.ActualValue = truncate(failure.message), truncate(failure.actual)
+	case ctx.Err() != nil:
+		res.Error = fmt.Sprintf("script did not finish within %s", timeout)
+		res.Status = -1
+	default:
+		requestError(&res, err)
+	}
+	return res
+}
+
+func (r *scriptRun) predeclared() starlark.StringDict {
+	return starlark.StringDict{
+		"http": &starlarkstruct.Module{Name: "http", Members: starlark.StringDict{
+			"get":     starlark.NewBuiltin("http.get", r.httpGet),
+			"post":    starlark.NewBuiltin("http.post", r.httpPost),
+			"request": starlark.NewBuiltin("http.request", r.httpRequest),
+		}},
+		"hash": &starlarkstruct.Module{Name: "hash", Members: starlark.StringDict{
+			"md5":    hashBuiltin("hash.md5", md5.New),
+			"sha1":   hashBuiltin("hash.sha1", sha1.New),
+			"sha256": hashBuiltin("hash.sha256", sha256.New),
+		}},
+		"json": json.Module,
+		"fail": starlark.NewBuiltin("fail", scriptFail),
+	}
+}
+
+func (r *scriptRun) httpGet(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var url string
+	var headers *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "url", &url, "headers?", &headers); err != nil {
+		return nil, err
+	}
+	return r.do(http.MethodGet, url, "", headers)
+}
+
+func (r *scriptRun) httpPost(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var url, body string
+	var headers *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "url", &url, "body?", &body, "headers?", &headers); err != nil {
+		return nil, err
+	}
+	return r.do(http.MethodPost, url, body, headers)
+}
+
+func (r *scriptRun) httpRequest(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var method, url, body string
+	var headers *starlark.Dict
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "method", &method, "url", &url, "body?", &body, "headers?", &headers); err != nil {
+		return nil, err
+	}
+	return r.do(strings.ToUpper(method), url, body, headers)
+}
+
+// do sends a request for the script and returns the response as a struct with status, headers, body and url
+func (r *scriptRun) do(method, url, body string, headers *starlark.Dict) (starlark.Value, error) {
+	spec := config.Request{Method: method, Headers: map[string]string{}}
+	if headers != nil {
+		for _, item := range headers.Items() {
+			name, ok1 := starlark.AsString(item[0])
+			value, ok2 := starlark.AsString(item[1])
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("headers must map strings to strings")
+			}
+			spec.Headers[name] = value
+		}
+	}
+	req, err := newRequest(r.ctx, url, spec.WithPayload([]byte(body)))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := proxyAuthRequired(r.proxy, resp); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	r.status = resp.StatusCode
+	respHeaders := starlark.NewDict(len(resp.Header))
+	for name, values := range resp.Header {
+		respHeaders.SetKey(starlark.String(strings.ToLower(name)), starlark.String(strings.Join(values, ", ")))
+	}
+	return starlarkstruct.FromStringDict(starlark.String("response"), starlark.StringDict{
+		"status":  starlark.MakeInt(resp.StatusCode),
+		"headers": respHeaders,
+		"body":    starlark.String(data),
+		"url":     starlark.String(resp.Request.URL.String()),
+	}), nil
+}
+
+func hashBuiltin(name string, newHash func() hash.Hash) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var data string
+		if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &data); err != nil {
+			return nil, err
+		}
+		h := newHash()
+		h.Write([]byte(data))
+		return starlark.String(hex.EncodeToString(h.Sum(nil))), nil
+	})
+}
+
+// scriptFail replaces the starlark fail builtin, fail(message, actual="") stops the script and fails the check
+func scriptFail(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var message string
+	var actual starlark.Value = starlark.String("")
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "message", &message, "actual?", &actual); err != nil {
+		return nil, err
+	}
+	failure := &scriptFailure{message: message}
+	if s, ok := starlark.AsString(actual); ok {
+		failure.actual = s
+	} else {
+		failure.actual = actual.String()
+	}
+	return nil, failure
+}
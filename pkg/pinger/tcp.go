@@ -0,0 +1,70 @@
+package pinger
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// longest banner read while waiting for the expected response
+const maxBannerBytes = 4096
+
+// timedDial connects to a tcp:// target, optionally sends the payload and waits for the expected pattern
+func timedDial(target config.Target, timeout time.Duration) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", target.Host())
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if target.Send != "" {
+		if _, err := conn.Write([]byte(target.Send)); err != nil {
+			res.Error = err.Error()
+			res.Status = -1
+			return res
+		}
+	}
+	if target.ExpectPattern() != nil {
+		banner, err := readUntil(conn, target)
+		res.ResponseMS = time.Since(start).Milliseconds()
+		if !target.ExpectPattern().Match(banner) {
+			res.FailedAssertion = "expect " + target.Expect
+			res.ActualValue = truncate(string(banner))
+			if len(banner) == 0 && err != nil {
+				res.Error = err.Error()
+				res.Status = -1
+			}
+		}
+	}
+	return res
+}
+
+// readUntil reads from the connection until the expected pattern shows up, the peer stops sending or the deadline hits
+func readUntil(conn net.Conn, target config.Target) ([]byte, error) {
+	var banner bytes.Buffer
+	buf := make([]byte, 1024)
+	for banner.Len() < maxBannerBytes {
+		n, err := conn.Read(buf)
+		banner.Write(buf[:n])
+		if target.ExpectPattern().Match(banner.Bytes()) {
+			return banner.Bytes(), nil
+		}
+		if err != nil {
+			return banner.Bytes(), err
+		}
+	}
+	return banner.Bytes(), nil
+}
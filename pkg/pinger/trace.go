@@ -0,0 +1,104 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases breaks an http check down by stage, each in milliseconds. Stages skipped on
+// a reused connection stay zero, TTFB is measured from the start of the request
+type Phases struct {
+	DNSMS      int64 `json:"dns_ms,omitempty"`
+	ConnectMS  int64 `json:"connect_ms,omitempty"`
+	TLSMS      int64 `json:"tls_ms,omitempty"`
+	TTFBMS     int64 `json:"ttfb_ms,omitempty"`
+	TransferMS int64 `json:"transfer_ms,omitempty"`
+}
+
+// phaseTrace collects httptrace timestamps, callbacks can fire from the dialer's goroutines so access is locked
+type phaseTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	remote       string //address of the connection used, or the last one tried
+}
+
+func newPhaseTrace(start time.Time) *phaseTrace {
+	return &phaseTrace{start: start}
+}
+
+func (p *phaseTrace) mark(t *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { p.mark(&p.dnsDone) },
+		ConnectStart: func(_, addr string) {
+			p.mark(&p.connectStart)
+			p.setRemote(addr)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.mark(&p.connectDone)
+			}
+		},
+		TLSHandshakeStart:    func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.mark(&p.tlsDone) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.setRemote(info.Conn.RemoteAddr().String())
+		},
+	}
+}
+
+func (p *phaseTrace) setRemote(addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remote = addr
+}
+
+// ip returns the ip of the connection the request went over, empty if none was made
+func (p *phaseTrace) ip() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	host, _, err := net.SplitHostPort(p.remote)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// phases converts the collected timestamps, bodyDone is when the body finished reading, zero if it never did
+func (p *phaseTrace) phases(bodyDone time.Time) Phases {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Phases{
+		DNSMS:      between(p.dnsStart, p.dnsDone),
+		ConnectMS:  between(p.connectStart, p.connectDone),
+		TLSMS:      between(p.tlsStart, p.tlsDone),
+		TTFBMS:     between(p.start, p.firstByte),
+		TransferMS: between(p.firstByte, bodyDone),
+	}
+}
+
+func between(from, to time.Time) int64 {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+	return to.Sub(from).Milliseconds()
+}
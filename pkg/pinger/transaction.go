@@ -0,0 +1,188 @@
+package pinger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+type StepResult struct {
+	Name            string `json:"name"`
+	URL             string `json:"url"`
+	Status          int    `json:"status"`
+	ResponseMS      int64  `json:"response_time_ms"`
+	Error           string `json:"error,omitempty"`
+	ProxyError      string `json:"proxy_error,omitempty"`
+	FailedAssertion string `json:"failed_assertion,omitempty"`
+	ActualValue     string `json:"actual_value,omitempty"`
+}
+
+// timedTransaction runs the steps of a transaction target in order under one timeout for the whole
+// run, and stops at the first failing step. The combined result fails when any step does
+func timedTransaction(target config.Target, timeout time.Duration, client *http.Client) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	jar, _ := cookiejar.New(nil)
+	c := *clientFor(target, client)
+	c.Jar = jar
+	vars := map[string]string{}
+	for _, step := range target.Steps {
+		stepRes := runStep(ctx, step, vars, &c, target.ProxyURL())
+		res.Steps = append(res.Steps, StepResult{
+			Name:            step.Name,
+			URL:             stepRes.URL,
+			Status:          stepRes.Status,
+			ResponseMS:      stepRes.ResponseMS,
+			Error:           stepRes.Error,
+			ProxyError:      stepRes.ProxyError,
+			FailedAssertion: stepRes.FailedAssertion,
+			ActualValue:     stepRes.ActualValue,
+		})
+		res.Status = stepRes.Status
+		res.statusExpected = stepRes.statusExpected
+		//certificates come from the first https step, so a run across hosts always reports the same one
+		if res.TLS == nil {
+			res.TLS = stepRes.TLS
+		}
+		if stepRes.ProxyError != "" {
+			res.ProxyError = step.Name + ": " + stepRes.ProxyError
+			break
+		}
+		if stepRes.Failed() {
+			res.Error = stepRes.Error
+			if stepRes.FailedAssertion != "" {
+				res.FailedAssertion = step.Name + ": " + stepRes.FailedAssertion
+				res.ActualValue = stepRes.ActualValue
+			}
+			break
+		}
+	}
+	res.ResponseMS = time.Since(start).Milliseconds()
+	return res
+}
+
+// runStep fills in the variables, sends the request, checks the response and extracts new variables
+func runStep(ctx context.Context, step config.Step, vars map[string]string, client *http.Client, proxy *url.URL) PingResult {
+	replacer := variableReplacer(vars, nil)
+	spec := step.Request
+	spec.Headers = make(map[string]string, len(step.Headers))
+	for name, value := range step.Headers {
+		spec.Headers[name] = replacer.Replace(value)
+	}
+	spec = spec.WithPayload([]byte(replacer.Replace(string(step.Payload()))))
+	stepURL := fillURL(step.URL, vars)
+
+	start := time.Now()
+	res := PingResult{URL: stepURL, TimestampUTC: start.UTC()}
+	req, err := newRequest(ctx, stepURL, spec)
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		res.ResponseMS = time.Since(start).Milliseconds()
+		requestError(&res, err)
+		res.TLS = tlsInfoFromError(err)
+		return res
+	}
+	defer resp.Body.Close()
+	if err := proxyAuthRequired(proxy, resp); err != nil {
+		res.ResponseMS = time.Since(start).Milliseconds()
+		requestError(&res, err)
+		return res
+	}
+	res.Status = resp.StatusCode
+	res.TLS = tlsInfoFromState(resp.TLS)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	res.ResponseMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = -1
+		return res
+	}
+	checkResponse(&res, body, step.ExpectedStatus, step.Checks)
+	if !res.Failed() {
+		if err := extract(step, resp, body, vars); err != nil {
+			res.FailedAssertion = err.Error()
+		}
+	}
+	return res
+}
+
+// extract captures the step's variables from the response headers, cookies or json body
+func extract(step config.Step, resp *http.Response, body []byte, vars map[string]string) error {
+	var doc any
+	decoded := false
+	for variable, source := range step.Extract {
+		kind, from, _ := strings.Cut(source, ":")
+		var value string
+		found := false
+		switch kind {
+		case "header":
+			value = resp.Header.Get(from)
+			found = value != ""
+		case "cookie":
+			for _, cookie := range resp.Cookies() {
+				if cookie.Name == from {
+					value, found = cookie.Value, true
+				}
+			}
+		case "json":
+			if !decoded {
+				if err := json.Unmarshal(body, &doc); err != nil {
+					return fmt.Errorf("extract %s from json body: %w", variable, err)
+				}
+				decoded = true
+			}
+			var raw any
+			if raw, found = step.ExtractPath(variable).Lookup(doc); found {
+				if s, ok := raw.(string); ok {
+					value = s
+				} else {
+					encoded, _ := json.Marshal(raw)
+					value = string(encoded)
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("extract %s from %s", variable, source)
+		}
+		vars[variable] = value
+	}
+	return nil
+}
+
+// fillURL replaces the variables in a step url, escaped for the part of the url they land in so a
+// captured value cannot change the path or add query parameters
+func fillURL(raw string, vars map[string]string) string {
+	base, query, hasQuery := strings.Cut(raw, "?")
+	filled := variableReplacer(vars, url.PathEscape).Replace(base)
+	if hasQuery {
+		filled += "?" + variableReplacer(vars, url.QueryEscape).Replace(query)
+	}
+	return filled
+}
+
+// variableReplacer replaces {{name}} with the captured values, passed through escape when it is set
+func variableReplacer(vars map[string]string, escape func(string) string) *strings.Replacer {
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		if escape != nil {
+			value = escape(value)
+		}
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,231 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// clients holds one client per distinct transport profile, each with its own connection pool
+var clients sync.Map
+
+// addressKey carries the ip a request is pinned to in its context, so targets that differ only by
+// address share one transport instead of growing the cache with every address they resolve to
+type addressKey struct{}
+
+// clientFor returns the shared client for plain targets, or a client whose transport is a
+// clone of the shared one with the target's tls profile, pinned protocol, resolver and proxy applied.
+// A pinned address is passed to the dialer per request, on connections that are not kept alive so
+// each request reaches the address it was given
+func clientFor(target config.Target, base *http.Client) *http.Client {
+	protocol := target.Protocol()
+	family := pinnedFamily(target)
+	address := target.Address()
+	if target.TLS == nil && protocol == "" && family == "" && address == "" && target.Resolver == "" && len(target.Resolve) == 0 && target.Proxy == "" {
+		return base
+	}
+	key := fmt.Sprint(protocol, "|", family, "|", address != "", "|", target.Resolver, "|", target.Resolve, "|", target.ProxyURL())
+	if target.TLS != nil {
+		key += "|" + target.TLS.Key()
+	}
+	c, ok := clients.Load(key)
+	if !ok {
+		c, _ = clients.LoadOrStore(key, &http.Client{Transport: newTransport(target, base, address != ""), Timeout: base.Timeout})
+	}
+	client := c.(*http.Client)
+	if address == "" {
+		return client
+	}
+	return &http.Client{Transport: pinnedAddress{client.Transport, address}, Timeout: client.Timeout}
+}
+
+// newTransport builds the transport for a profile, taking its timeouts from the base client's
+func newTransport(target config.Target, base *http.Client, pinned bool) http.RoundTripper {
+	var tlsConfig *tls.Config
+	if target.TLS != nil {
+		tlsConfig = target.TLS.TLSConfig()
+	}
+	baseTransport := base.Transport.(*http.Transport)
+	if target.Protocol() == "http3" {
+		quicConfig := &quic.Config{HandshakeIdleTimeout: baseTransport.TLSHandshakeTimeout, MaxIdleTimeout: baseTransport.IdleConnTimeout}
+		newH3 := func() *http3.Transport {
+			return &http3.Transport{TLSClientConfig: tlsConfig, QUICConfig: quicConfig, Dial: pinnedQUICDial(target)}
+		}
+		if pinned {
+			return quicPerRequest(newH3)
+		}
+		return newH3()
+	}
+	t := baseTransport.Clone()
+	t.TLSClientConfig = tlsConfig
+	//a custom tls config turns off http2 unless it is asked for
+	t.ForceAttemptHTTP2 = true
+	t.DialContext = pinnedDial(target)
+	t.DisableKeepAlives = pinned
+	SetProxy(t, target.ProxyURL())
+	if protocol := target.Protocol(); protocol != "" {
+		t.Protocols = pinnedProtocols(protocol)
+	}
+	return t
+}
+
+// pinnedAddress hands the address a request is pinned to down to the dialer
+type pinnedAddress struct {
+	next    http.RoundTripper
+	address string
+}
+
+func (p pinnedAddress) RoundTrip(req *http.Request) (*http.Response, error) {
+	return p.next.RoundTrip(req.WithContext(context.WithValue(req.Context(), addressKey{}, p.address)))
+}
+
+// quicPerRequest sends each request over its own http3 transport, closed with the response body,
+// since http3 pools connections by host with no way to turn reuse off
+type quicPerRequest func() *http3.Transport
+
+func (q quicPerRequest) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := q()
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+	resp.Body = closeWith{resp.Body, t}
+	return resp, nil
+}
+
+// closeWith closes an extra resource after the body it wraps
+type closeWith struct {
+	io.ReadCloser
+	also io.Closer
+}
+
+func (c closeWith) Close() error {
+	err := c.ReadCloser.Close()
+	c.also.Close()
+	return err
+}
+
+// pinnedFamily is the single address family a target connects over, empty when any will do
+func pinnedFamily(target config.Target) string {
+	if target.AddressFamily == "ipv4" || target.AddressFamily == "ipv6" {
+		return target.AddressFamily
+	}
+	return ""
+}
+
+// familyNetwork narrows a network such as tcp or ip to the pinned family
+func familyNetwork(network, family string) string {
+	switch family {
+	case "ipv4":
+		return network + "4"
+	case "ipv6":
+		return network + "6"
+	}
+	return network
+}
+
+// pinnedIPs returns the ips a dial to addr must use, from the address the request is pinned to or
+// the target's own, then its resolve overrides, nil when the host resolves as usual
+func pinnedIPs(ctx context.Context, target config.Target, addr string) []string {
+	host, _, _ := net.SplitHostPort(addr)
+	ip, ok := ctx.Value(addressKey{}).(string)
+	if !ok {
+		ip = target.Address()
+	}
+	if ip != "" && strings.EqualFold(host, target.Hostname()) {
+		return []string{ip}
+	}
+	return target.Overrides(addr)
+}
+
+// pinnedDial connects to the pinned ips of addr in order, or resolves it through the target's resolver
+// keeping to its pinned family
+func pinnedDial(target config.Target) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: newResolver(target.Resolver)}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ips := pinnedIPs(ctx, target, addr)
+		if len(ips) == 0 {
+			return dialer.DialContext(ctx, familyNetwork(network, pinnedFamily(target)), addr)
+		}
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// pinnedQUICDial does the same for http3, reporting the address it dials to the request's trace
+func pinnedQUICDial(target config.Target) func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	return func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ip := ""
+		if ips := pinnedIPs(ctx, target, addr); len(ips) > 0 {
+			ip = ips[0]
+		} else {
+			found, err := newResolver(target.Resolver).LookupNetIP(ctx, familyNetwork("ip", pinnedFamily(target)), host)
+			if err != nil {
+				return nil, err
+			}
+			ip = found[0].String()
+		}
+		addr = net.JoinHostPort(ip, port)
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.ConnectStart != nil {
+			trace.ConnectStart("udp", addr)
+		}
+		conn, err := quic.DialAddrEarly(ctx, addr, tlsCfg, cfg)
+		if trace != nil && trace.ConnectDone != nil {
+			trace.ConnectDone("udp", addr, err)
+		}
+		return conn, err
+	}
+}
+
+// pinnedProtocols allows a single http version, http2 on a plain http url is sent with prior knowledge
+func pinnedProtocols(protocol string) *http.Protocols {
+	p := &http.Protocols{}
+	switch protocol {
+	case "http1":
+		p.SetHTTP1(true)
+	case "http2":
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+	}
+	return p
+}
+
+// targetTLSConfig is the tls config for probes that dial themselves, verifying against the target's host
+// unless its profile names a server
+func targetTLSConfig(target config.Target) *tls.Config {
+	if target.TLS != nil {
+		cfg := target.TLS.TLSConfig()
+		if cfg.ServerName == "" {
+			cfg.ServerName = target.Hostname()
+		}
+		return cfg
+	}
+	return &tls.Config{ServerName: target.Hostname()}
+}
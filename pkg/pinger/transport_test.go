@@ -0,0 +1,48 @@
+package pinger
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientForPinnedAddresses(t *testing.T) {
+	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		host, _, _ := net.SplitHostPort(local.String())
+		io.WriteString(w, host)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	targets := loadTargets(t, fmt.Sprintf(`{"targets":[{"url":"http://pinned.test:%s"}]}`, port))
+	base := &http.Client{Transport: &http.Transport{TLSHandshakeTimeout: time.Second}, Timeout: 2 * time.Second}
+	first := clientFor(targets[0].WithAddress("127.0.0.1"), base)
+	second := clientFor(targets[0].WithAddress("127.0.0.2"), base)
+	if first.Transport.(pinnedAddress).next != second.Transport.(pinnedAddress).next {
+		t.Fatal("targets differing only by address got separate transports")
+	}
+	for range 2 {
+		for client, want := range map[*http.Client]string{first: "127.0.0.1", second: "127.0.0.2"} {
+			resp, err := client.Get(fmt.Sprintf("http://pinned.test:%s/", port))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != want {
+				t.Errorf("request pinned to %s reached %s", want, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package pinger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// variant is one way of probing a target, named when the target is probed in several ways
+type variant struct {
+	name   string
+	target config.Target
+}
+
+// variants expands a target into every combination of its protocols and address families or addresses
+func variants(target config.Target, timeout time.Duration) ([]variant, error) {
+	list := []variant{{target: target}}
+	if len(target.Protocols) > 1 {
+		list = expand(list, target.Protocols, func(t config.Target, protocol string) config.Target {
+			t.Protocols = []string{protocol}
+			return t
+		})
+	}
+	if pinned := target.Pinned(); len(pinned) > 0 {
+		ips := pinnedOfFamily(pinned, pinnedFamily(target))
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no %s address among the resolve overrides %v", target.AddressFamily, pinned)
+		}
+		if len(ips) == 1 {
+			//a single backend keeps the plain url as its series
+			for i := range list {
+				list[i].target = list[i].target.WithAddress(ips[0])
+			}
+			return list, nil
+		}
+		return expand(list, ips, config.Target.WithAddress), nil
+	}
+	switch target.AddressFamily {
+	case "both":
+		list = expand(list, []string{"ipv4", "ipv6"}, func(t config.Target, family string) config.Target {
+			t.AddressFamily = family
+			return t
+		})
+	case "each":
+		ips, err := resolveAll(target, timeout)
+		if err != nil {
+			return nil, err
+		}
+		list = expand(list, ips, config.Target.WithAddress)
+	}
+	return list, nil
+}
+
+func expand(list []variant, values []string, apply func(config.Target, string) config.Target) []variant {
+	expanded := make([]variant, 0, len(list)*len(values))
+	for _, v := range list {
+		for _, value := range values {
+			name := value
+			if v.name != "" {
+				name = v.name + "/" + value
+			}
+			expanded = append(expanded, variant{name: name, target: apply(v.target, value)})
+		}
+	}
+	return expanded
+}
+
+// pinnedOfFamily keeps the pinned ips of a family, all of them when no family is pinned
+func pinnedOfFamily(ips []string, family string) []string {
+	if family == "" {
+		return ips
+	}
+	var kept []string
+	for _, ip := range ips {
+		if (net.ParseIP(ip).To4() != nil) == (family == "ipv4") {
+			kept = append(kept, ip)
+		}
+	}
+	return kept
+}
+
+// resolveAll looks up every address of the target's host, so each can be probed on its own
+func resolveAll(target config.Target, timeout time.Duration) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := newResolver(target.Resolver).LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.Unmap().String())
+	}
+	return ips, nil
+}
@@ -0,0 +1,96 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// timedWebSocket upgrades a ws:// or wss:// target and, when send or expect is set, waits for a reply
+func timedWebSocket(target config.Target, timeout time.Duration, client *http.Client) PingResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	//headers and auth are collected on a throwaway request so they match http targets
+	req, err := newRequest(ctx, target.URL, config.Request{Method: http.MethodGet, Headers: target.Headers})
+	if err == nil {
+		err = authorize(ctx, req, target.Auth, clientFor(target, client))
+		if err != nil {
+			return PingResult{URL: target.URL, AuthError: err.Error(), TimestampUTC: time.Now().UTC()}
+		}
+	}
+	if err != nil {
+		return PingResult{URL: target.URL, Status: -1, Error: err.Error(), TimestampUTC: time.Now().UTC()}
+	}
+	if req.Host != req.URL.Host {
+		req.Header.Set("Host", req.Host)
+	}
+
+	dialer := websocket.Dialer{}
+	if target.TLS != nil {
+		dialer.TLSClientConfig = target.TLS.TLSConfig()
+	}
+	start := time.Now()
+	res := PingResult{URL: target.URL, TimestampUTC: start.UTC()}
+	conn, resp, err := dialer.DialContext(ctx, target.URL, req.Header)
+	res.HandshakeMS = time.Since(start).Milliseconds()
+	res.ResponseMS = res.HandshakeMS
+	if err != nil {
+		res.Error = err.Error()
+		if resp != nil {
+			res.Error = fmt.Sprintf("%s (status %d)", err, resp.StatusCode)
+		}
+		res.Status = -1
+		res.TLS = tlsInfoFromError(err)
+		return res
+	}
+	defer conn.Close()
+	res.Status = resp.StatusCode
+	if tlsConn, ok := conn.NetConn().(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		res.TLS = tlsInfoFromState(&state)
+	}
+	if target.Send == "" && target.ExpectPattern() == nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		return res
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+	conn.SetWriteDeadline(deadline)
+	sent := time.Now()
+	if target.Send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(target.Send)); err != nil {
+			res.Error = err.Error()
+			res.Status = -1
+			return res
+		}
+	}
+	//read until a message matches, any message counts when nothing is expected
+	var last []byte
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			res.ResponseMS = time.Since(start).Milliseconds()
+			res.FailedAssertion = "reply within timeout"
+			if target.ExpectPattern() != nil {
+				res.FailedAssertion = "expect " + target.Expect
+			}
+			res.ActualValue = truncate(string(last))
+			res.Error = err.Error()
+			return res
+		}
+		last = msg
+		if target.ExpectPattern() == nil || target.ExpectPattern().Match(msg) {
+			break
+		}
+	}
+	res.RoundTripMS = time.Since(sent).Milliseconds()
+	res.ResponseMS = time.Since(start).Milliseconds()
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return res
+}
@@ -0,0 +1,85 @@
+package pinger
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
+)
+
+// byteCounter counts what passes through it
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// readBody reads the whole response so its weight is known, counting the bytes transferred and
+// the bytes after decoding. Only the first maxBodyBytes of the decoded body are kept for assertions
+func readBody(resp *http.Response, res *PingResult) ([]byte, error) {
+	wire := &byteCounter{}
+	raw := io.TeeReader(resp.Body, wire)
+	res.ContentEncoding = strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var decoded io.Reader = raw
+	var err error
+	switch res.ContentEncoding {
+	case "gzip", "x-gzip":
+		decoded, err = gzip.NewReader(raw)
+	case "deflate":
+		decoded, err = zlib.NewReader(raw)
+	}
+	if err == io.EOF {
+		//HEAD requests and empty responses can still name an encoding
+		decoded, err = raw, nil
+	}
+	body := &cappedBuffer{limit: maxBodyBytes}
+	if !decodable(res.ContentEncoding) {
+		//an encoding that cannot be decoded here, only its transferred size is known
+		_, err = io.Copy(body, raw)
+		res.TransferBytes = wire.n
+		return body.Bytes(), err
+	}
+	if err == nil {
+		res.BodyBytes, err = io.Copy(body, decoded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s body: %w", res.ContentEncoding, err)
+	}
+	//anything after the compressed stream still went over the wire
+	io.Copy(io.Discard, raw)
+	res.TransferBytes = wire.n
+	return body.Bytes(), nil
+}
+
+func decodable(encoding string) bool {
+	switch encoding {
+	case "", "identity", "gzip", "x-gzip", "deflate":
+		return true
+	}
+	return false
+}
+
+// checkBudget lists the limits of the target's budget the result went over
+func checkBudget(res *PingResult, budget *config.Budget) {
+	if budget == nil {
+		return
+	}
+	over := func(name string, actual, limit int64) {
+		if limit > 0 && actual > limit {
+			res.BudgetViolations = append(res.BudgetViolations, fmt.Sprintf("%s %d > %d", name, actual, limit))
+		}
+	}
+	if decodable(res.ContentEncoding) {
+		over("body_bytes", res.BodyBytes, budget.MaxBodyBytes)
+	}
+	over("transfer_bytes", res.TransferBytes, budget.MaxTransferBytes)
+	over("latency_ms", res.ResponseMS, budget.MaxLatencyMS)
+	over("ttfb_ms", res.TTFBMS, budget.MaxTTFBMS)
+}
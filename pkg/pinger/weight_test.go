@@ -0,0 +1,56 @@
+package pinger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUndecodableBody(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte(`{"status": "ok"}`))
+	zw.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Accept-Encoding") {
+		case "br":
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte{0x1b, 0x0f, 0x00, 0xf8, 0x25, 0x82, 0x02, 0x00})
+		case "gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped.Bytes())
+		}
+	}))
+	t.Cleanup(srv.Close)
+	tests := []struct {
+		name     string
+		options  string
+		failed   string
+		hashable bool
+	}{
+		{name: "br without body checks", options: `"headers":{"Accept-Encoding":"br"}`},
+		{name: "br with assertions", options: `"headers":{"Accept-Encoding":"br"},"assertions":[{"type":"contains","value":"ok"}]`, failed: "cannot decode br body"},
+		{name: "br with json assertions", options: `"headers":{"Accept-Encoding":"br"},"json_assertions":["$.status == \"ok\""]`, failed: "cannot decode br body"},
+		{name: "br with content tracking", options: `"headers":{"Accept-Encoding":"br"},"content_tracking":{}`, failed: "cannot decode br body"},
+		{name: "br with unexpected status", options: `"headers":{"Accept-Encoding":"br"},"assertions":[{"type":"contains","value":"ok"}],"expected_status":[204]`, failed: "status in [204]"},
+		{name: "gzip is decoded", options: `"headers":{"Accept-Encoding":"gzip"},"json_assertions":["$.status == \"ok\""],"content_tracking":{}`, hashable: true},
+	}
+	client := &http.Client{Transport: &http.Transport{}, Timeout: time.Second}
+	for _, tt := range tests {
+		targets := loadTargets(t, fmt.Sprintf(`{"targets":[{"url":%q,%s}]}`, srv.URL, tt.options))
+		res := timedGet(targets[0], time.Second, client)
+		if res.Error != "" || res.FailedAssertion != tt.failed {
+			t.Errorf("%s: got error %q assertion %q, want assertion %q", tt.name, res.Error, res.FailedAssertion, tt.failed)
+		}
+		if (res.ContentHash != "") != tt.hashable {
+			t.Errorf("%s: got content hash %q, want one %v", tt.name, res.ContentHash, tt.hashable)
+		}
+		if res.TransferBytes == 0 {
+			t.Errorf("%s: transfer bytes not counted", tt.name)
+		}
+	}
+}
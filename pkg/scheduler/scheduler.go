@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/sairamkumarm/gositemonitor/pkg/config"
 )
 
 func PermitHandler(permits chan struct{}, rateLimitPerSec int, finish context.Context, wg *sync.WaitGroup) {
@@ -23,7 +25,7 @@ mainloop:
 	}
 }
 
-func JobHandler(jobs chan string, urls []string, rateLimitPerSec int, requestIntervalDuration time.Duration, finish context.Context, wg *sync.WaitGroup) {
+func JobHandler(jobs chan config.Target, targets []config.Target, rateLimitPerSec int, requestIntervalDuration time.Duration, finish context.Context, wg *sync.WaitGroup) {
 	defer func() {
 		fmt.Println("Deactivating Job Refiller")
 		wg.Done()
@@ -32,12 +34,12 @@ func JobHandler(jobs chan string, urls []string, rateLimitPerSec int, requestInt
 	defer ticker.Stop()
 mainloop:
 	for {
-		for _, url := range urls {
+		for _, target := range targets {
 			for range rateLimitPerSec {
 				select {
 				case <-finish.Done():
 					break mainloop
-				case jobs <- url:
+				case jobs <- target:
 					//enqueue
 				}
 			}